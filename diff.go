@@ -0,0 +1,85 @@
+// Copyright (C) 2018, Michael J. Fromberger
+// All Rights Reserved.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// diffContext is the number of unchanged lines shown around a change.
+const diffContext = 3
+
+// writeDiff writes a unified diff from a (labelled oldName) to b (labelled
+// newName) to w. If a and b are equal, nothing is written.
+//
+// The diff consists of a single hunk spanning from the first to the last line
+// that differs. This is not minimal in general, but is for the changes made by
+// this tool, which add or remove text in one place.
+func writeDiff(w io.Writer, oldName, newName string, a, b []byte) {
+	if bytes.Equal(a, b) {
+		return
+	}
+	x, y := splitLines(a), splitLines(b)
+
+	// Find the extent of the common prefix and suffix.
+	pre := 0
+	for pre < len(x) && pre < len(y) && x[pre] == y[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(x)-pre && suf < len(y)-pre && x[len(x)-1-suf] == y[len(y)-1-suf] {
+		suf++
+	}
+
+	lo := max(0, pre-diffContext)
+	post := min(suf, diffContext)
+	hiX, hiY := len(x)-suf+post, len(y)-suf+post
+
+	fmt.Fprintf(w, "--- %s\n+++ %s\n", oldName, newName)
+	fmt.Fprintf(w, "@@ -%s +%s @@\n", diffSpan(lo, hiX-lo), diffSpan(lo, hiY-lo))
+	writeLines(w, " ", x[lo:pre])
+	writeLines(w, "-", x[pre:len(x)-suf])
+	writeLines(w, "+", y[pre:len(y)-suf])
+	writeLines(w, " ", x[len(x)-suf:hiX])
+}
+
+// diffSpan formats the range of n lines beginning at offset pos for a hunk
+// header.
+func diffSpan(pos, n int) string {
+	switch n {
+	case 0:
+		return fmt.Sprintf("%d,0", pos)
+	case 1:
+		return fmt.Sprint(pos + 1)
+	default:
+		return fmt.Sprintf("%d,%d", pos+1, n)
+	}
+}
+
+// writeLines writes each of lines to w with the given tag prefixed.
+func writeLines(w io.Writer, tag string, lines []string) {
+	for _, line := range lines {
+		fmt.Fprint(w, tag, line)
+		if line == "" || line[len(line)-1] != '\n' {
+			fmt.Fprint(w, "\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// splitLines splits data into lines, each including its line terminator if it
+// has one.
+func splitLines(data []byte) []string {
+	var lines []string
+	for len(data) != 0 {
+		i := bytes.IndexByte(data, '\n') + 1
+		if i == 0 {
+			i = len(data)
+		}
+		lines = append(lines, string(data[:i]))
+		data = data[i:]
+	}
+	return lines
+}
@@ -8,15 +8,20 @@ import "github.com/creachadair/lice/licenses"
 
 func init() {
 	licenses.Register(licenses.License{
-		Name:    "Apache License, Version 2.0",
-		Slug:    "apache2.0",
-		URL:     "https://directory.fsf.org/wiki/License:Apache-2.0",
-		Text:    text,
-		PerFile: perFile,
+		Name:     "Apache License, Version 2.0",
+		Slug:     "apache2.0",
+		Aliases:  []string{"apache", "apache2"},
+		URL:      "https://directory.fsf.org/wiki/License:Apache-2.0",
+		SPDX:     "Apache-2.0",
+		Category: licenses.Permissive,
+		Text:     text,
+		PerFile:  perFile,
+		Notice:   notice,
 	})
 }
 
 const text = `
+                                 Apache License
                         Version 2.0, January 2004
                      http://www.apache.org/licenses/
 
@@ -192,10 +197,27 @@ TERMS AND CONDITIONS FOR USE, REPRODUCTION, AND DISTRIBUTION
    of your accepting any such warranty or additional liability.
 
 END OF TERMS AND CONDITIONS
-`
+
+APPENDIX: How to apply the Apache License to your work.
+
+   To apply the Apache License to your work, attach the following
+   boilerplate notice, with the fields enclosed by brackets "[]"
+   replaced with your own identifying information. (Don't include
+   the brackets!)  The text should be enclosed in the appropriate
+   comment syntax for the file format. We also recommend that a
+   file or class name and description of purpose be included on the
+   same "printed page" as the copyright notice for easier
+   identification within third-party archives.
+
+Copyright [yyyy] [name of copyright owner]
+` + boilerplate
+
+// The per-file notice is the boilerplate from the appendix of the license.
 const perFile = `
-Copyright {{date "2006"}} {{.Author}}. All Rights Reserved.
+Copyright {{date "2006"}} {{authors}}
+` + boilerplate
 
+const boilerplate = `
 Licensed under the Apache License, Version 2.0 (the "License");
 you may not use this file except in compliance with the License.
 You may obtain a copy of the License at
@@ -208,3 +230,10 @@ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
 See the License for the specific language governing permissions and
 limitations under the License.
 `
+
+const notice = `
+{{if .Project}}{{.Project}}
+{{end}}Copyright {{date "2006"}} {{authors}}
+
+This product includes software developed by {{authors}}.
+`
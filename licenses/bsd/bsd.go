@@ -7,18 +7,48 @@ import "github.com/creachadair/lice/licenses"
 
 func init() {
 	licenses.Register(licenses.License{
-		Name:    "Modified BSD license (3-clause)",
-		Slug:    "bsd3c",
-		URL:     "https://directory.fsf.org/wiki/License:BSD-3-Clause",
-		Text:    bsd3text,
-		PerFile: licenses.PerFileNotice,
+		Name:     "Modified BSD license (3-clause)",
+		Slug:     "bsd3c",
+		Aliases:  []string{"bsd", "bsd3"},
+		URL:      "https://directory.fsf.org/wiki/License:BSD-3-Clause",
+		SPDX:     "BSD-3-Clause",
+		Category: licenses.Permissive,
+		Text:     bsd3text,
+		PerFile:  licenses.PerFileNotice,
+	})
+	licenses.Register(licenses.License{
+		Name:     "Simplified BSD license (2-clause)",
+		Slug:     "bsd2c",
+		Aliases:  []string{"bsd2"},
+		URL:      "https://opensource.org/license/bsd-2-clause",
+		SPDX:     "BSD-2-Clause",
+		Category: licenses.Permissive,
+		Text:     bsd2text,
+		PerFile:  licenses.PerFileNotice,
 	})
 }
 
+const bsd2text = `
+BSD 2-Clause License
+
+Copyright (C) {{date "2006"}}, {{authors}}
+All Rights Reserved.
+
+Redistribution and use in source and binary forms, with or without
+modification, are permitted provided that the following conditions are met:
+
+    (1) Redistributions of source code must retain the above copyright notice,
+    this list of conditions and the following disclaimer.
+
+    (2) Redistributions in binary form must reproduce the above copyright
+    notice, this list of conditions and the following disclaimer in the
+    documentation and/or other materials provided with the distribution.
+` + disclaimer
+
 const bsd3text = `
 BSD 3-Clause License
 
-Copyright (C) {{date "2006"}}, {{.Author}}
+Copyright (C) {{date "2006"}}, {{authors}}
 All Rights Reserved.
 
 Redistribution and use in source and binary forms, with or without
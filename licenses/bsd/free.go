@@ -6,18 +6,20 @@ import "github.com/creachadair/lice/licenses"
 
 func init() {
 	licenses.Register(licenses.License{
-		Name:    "FreeBSD software license",
-		Slug:    "freebsd",
-		URL:     "https://www.freebsd.org/copyright/freebsd-license.html",
-		Text:    freetext,
-		PerFile: licenses.PerFileNotice,
+		Name:     "FreeBSD software license",
+		Slug:     "freebsd",
+		URL:      "https://www.freebsd.org/copyright/freebsd-license.html",
+		SPDX:     "BSD-2-Clause-Views",
+		Category: licenses.Permissive,
+		Text:     freetext,
+		PerFile:  licenses.PerFileNotice,
 	})
 }
 
 const freetext = `
 BSD 2-Clause FreeBSD License
 
-Copyright {{date "2006"}}, {{.Author}}
+Copyright {{date "2006"}}, {{authors}}
 All Rights Reserved.
 
 Redistribution and use in source and binary forms, with or without
@@ -0,0 +1,32 @@
+// Copyright (C) 2018 Michael J. Fromberger. All Rights Reserved.
+
+package bsd
+
+import "github.com/creachadair/lice/licenses"
+
+func init() {
+	licenses.Register(licenses.License{
+		Name:     "BSD Zero Clause License",
+		Slug:     "0bsd",
+		URL:      "https://opensource.org/license/0bsd",
+		SPDX:     "0BSD",
+		Category: licenses.PublicDomain,
+		Text:     zerotext,
+		PerFile:  licenses.PerFileNotice,
+	})
+}
+
+const zerotext = `
+Copyright (C) {{date "2006"}} by {{authors}}
+
+Permission to use, copy, modify, and/or distribute this software for any
+purpose with or without fee is hereby granted.
+
+THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+`
@@ -0,0 +1,96 @@
+// Copyright (C) 2018 Michael J. Fromberger. All Rights Reserved.
+
+package cc
+
+import "github.com/creachadair/lice/licenses"
+
+func init() {
+	licenses.Register(licenses.License{
+		Name:     "Creative Commons Attribution 4.0 International",
+		Slug:     "cc-by4",
+		Aliases:  []string{"cc-by"},
+		URL:      "https://creativecommons.org/licenses/by/4.0/legalcode",
+		SPDX:     "CC-BY-4.0",
+		Category: licenses.Permissive,
+		Text: `
+Creative Commons Attribution 4.0 International (CC BY 4.0)
+` + byIntro + `https://creativecommons.org/licenses/by/4.0/legalcode
+` + byFreedoms + byTerms + byNotices,
+		PerFile: `
+{{or .Project "This work"}} © {{date "2006"}} by {{authors}} is licensed under
+CC BY 4.0. To view a copy of this license, visit
+https://creativecommons.org/licenses/by/4.0/
+`,
+		DefaultIndent: licenses.INone(),
+	})
+	licenses.Register(licenses.License{
+		Name:     "Creative Commons Attribution-ShareAlike 4.0 International",
+		Slug:     "cc-by-sa4",
+		Aliases:  []string{"cc-by-sa"},
+		URL:      "https://creativecommons.org/licenses/by-sa/4.0/legalcode",
+		SPDX:     "CC-BY-SA-4.0",
+		Category: licenses.StrongCopyleft,
+		Text: `
+Creative Commons Attribution-ShareAlike 4.0 International (CC BY-SA 4.0)
+` + byIntro + `https://creativecommons.org/licenses/by-sa/4.0/legalcode
+` + byFreedoms + byTerms + `
+  ShareAlike - If you remix, transform, or build upon the material, you must
+  distribute your contributions under the same license as the original.
+` + byNotices,
+		PerFile: `
+{{or .Project "This work"}} © {{date "2006"}} by {{authors}} is licensed under
+CC BY-SA 4.0. To view a copy of this license, visit
+https://creativecommons.org/licenses/by-sa/4.0/
+`,
+		DefaultIndent: licenses.INone(),
+	})
+}
+
+// The texts of the Attribution licenses are the human-readable summaries of
+// the licenses, which link to the full legal code.
+
+const byIntro = `
+Copyright (C) {{date "2006"}} {{authors}}
+
+This is a human-readable summary of (and not a substitute for) the license.
+The full legal code of the license is available at:
+
+    `
+
+const byFreedoms = `
+You are free to:
+
+  Share - copy and redistribute the material in any medium or format for any
+  purpose, even commercially.
+
+  Adapt - remix, transform, and build upon the material for any purpose, even
+  commercially.
+
+The licensor cannot revoke these freedoms as long as you follow the license
+terms.
+`
+
+const byTerms = `
+Under the following terms:
+
+  Attribution - You must give appropriate credit, provide a link to the
+  license, and indicate if changes were made. You may do so in any reasonable
+  manner, but not in any way that suggests the licensor endorses you or your
+  use.
+`
+
+const byNotices = `
+  No additional restrictions - You may not apply legal terms or technological
+  measures that legally restrict others from doing anything the license
+  permits.
+
+Notices:
+
+  You do not have to comply with the license for elements of the material in
+  the public domain or where your use is permitted by an applicable exception
+  or limitation.
+
+  No warranties are given. The license may not give you all of the permissions
+  necessary for your intended use. For example, other rights such as
+  publicity, privacy, or moral rights may limit how you use the material.
+`
@@ -7,16 +7,18 @@ import "github.com/creachadair/lice/licenses"
 
 func init() {
 	licenses.Register(licenses.License{
-		Name:    "Creative Commons CC0",
-		Slug:    "cc0",
-		URL:     "https://creativecommons.org/publicdomain/zero/1.0/legalcode",
-		Text:    cc0text,
-		PerFile: cc0file,
+		Name:     "Creative Commons CC0",
+		Slug:     "cc0",
+		URL:      "https://creativecommons.org/publicdomain/zero/1.0/legalcode",
+		SPDX:     "CC0-1.0",
+		Category: licenses.PublicDomain,
+		Text:     cc0text,
+		PerFile:  cc0file,
 	})
 }
 
 const cc0text = `
-This software is released to the public domain by {{.Author}} under the terms
+This software is released to the public domain by {{authors}} under the terms
 of Creative Commons CC0.
 
 -- Statement of Purpose
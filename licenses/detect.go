@@ -0,0 +1,386 @@
+// Copyright (C) 2018 Michael J. Fromberger. All Rights Reserved.
+
+package licenses
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DetectLicense reads the text of a license from r and reports which of the
+// registered licenses it most closely matches, along with a confidence score
+// between 0 and 1. Comparison ignores case, punctuation, and whitespace, as
+// well as copyright lines and the fields filled in by the license template,
+// so that a rendered license file matches its template.
+//
+// If no licenses are registered, DetectLicense returns nil, 0, nil.
+func DetectLicense(r io.Reader) (*License, float64, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	want := shingles(words(dropCopyright(string(data))))
+
+	var best *License
+	var score float64
+	for _, lic := range Licenses() {
+		t, err := lic.parse(lic.cleanup(lic.Text, 0).String(), lic.delims(lic.Text))
+		if err != nil {
+			continue // a broken template cannot match anything
+		}
+		got := shingles(words(dropCopyright(strings.Join(literalParts(t), " "))))
+		if s := similarity(want, got); best == nil || s > score {
+			lic := lic
+			best, score = &lic, s
+		}
+	}
+	return best, score, nil
+}
+
+// maxNoticeOffset is the number of bytes at the head of a file that are
+// searched for a per-file notice.
+const maxNoticeOffset = 16 << 10
+
+// HasNotice reports whether the text read from r contains the per-file notice
+// for the license near its beginning, or near its end as appended by EditBytes
+// with Config.AtEnd. The notice is matched as it would be rendered with
+// indent, but ignoring case, punctuation, whitespace, and the fields filled in
+// by the template, so that a notice with a different year or author, or with
+// a different comment style, still matches. If the license has no per-file
+// text, HasNotice reports false.
+func (lic *License) HasNotice(r io.Reader, indent Indenting) (bool, error) {
+	if lic == nil || lic.PerFile == "" {
+		return false, nil
+	}
+	re, err := lic.noticePattern(indent)
+	if err != nil {
+		return false, err
+	}
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return false, err
+	}
+	return hasNotice(re, src), nil
+}
+
+// hasNotice reports whether re, a notice pattern, matches near the beginning
+// or the end of src, as described by HasNotice.
+func hasNotice(re *regexp.Regexp, src []byte) bool {
+	head, tail := src[:min(len(src), maxNoticeOffset)], src[max(0, len(src)-maxNoticeOffset):]
+	return re.Match(head) || re.Match(tail)
+}
+
+// spdxTag matches an SPDX license identifier tag, capturing the identifier.
+var spdxTag = regexp.MustCompile(`SPDX-License-Identifier:[ \t]*([-\w.+]+)`)
+
+// DetectNotice reads the text of a file from r and reports which registered
+// license its per-file notice identifies, where indent is the comment style
+// of the file, as described by DetectNotices. If no license is identified, or
+// if the notice matches several licenses equally well, DetectNotice returns
+// nil, nil.
+func DetectNotice(r io.Reader, indent Indenting) (*License, error) {
+	lics, err := DetectNotices(r, indent)
+	if err != nil || len(lics) != 1 {
+		return nil, err
+	}
+	return &lics[0], nil
+}
+
+// DetectNotices reads the text of a file from r and reports which registered
+// licenses its per-file notice may identify, where indent is the comment style
+// of the file. An SPDX-License-Identifier tag naming a registered license
+// takes precedence. Otherwise, the licenses whose per-file notices match the
+// most text near the beginning of the file, as reported by HasNotice, are
+// chosen. Several licenses are reported only if they match equally well, as
+// licenses that share a generic notice do. If no license is identified,
+// DetectNotices returns nil, nil.
+func DetectNotices(r io.Reader, indent Indenting) ([]License, error) {
+	head, err := io.ReadAll(io.LimitReader(r, maxNoticeOffset))
+	if err != nil {
+		return nil, err
+	}
+	lics := Licenses()
+	if m := spdxTag.FindSubmatch(head); m != nil {
+		for _, lic := range lics {
+			if lic.SPDX == string(m[1]) {
+				return []License{lic}, nil
+			}
+		}
+	}
+
+	var best []License
+	var bestLen int
+	for _, lic := range lics {
+		if lic.PerFile == "" {
+			continue
+		}
+		re, err := lic.noticePattern(indent)
+		if err != nil {
+			return nil, err
+		}
+		loc := re.FindIndex(head)
+		if loc == nil {
+			continue
+		}
+		if n := loc[1] - loc[0]; n > bestLen {
+			best, bestLen = []License{lic}, n
+		} else if n == bestLen {
+			best = append(best, lic)
+		}
+	}
+	return best, nil
+}
+
+// noticePattern compiles a regular expression that matches the per-file
+// notice for lic as rendered with indent. Words in the literal text of the
+// template must match in order, separated by any non-word characters, and
+// each action or control structure in the template matches any text spanning
+// a few lines at most, as reported by nodeGap. As exceptions, a conditional
+// whose body is only literal text, such as an optional paragraph, matches
+// that text if it is present, the "copyright" function matches a copyright
+// symbol, optionally preceded by "Copyright", and the "spdx" function matches
+// the SPDX identifier of lic, if it has one.
+func (lic *License) noticePattern(indent Indenting) (*regexp.Regexp, error) {
+	t, err := lic.parse(lic.cleanup(lic.PerFile, 0).String(), lic.delims(lic.PerFile))
+	if err != nil {
+		return nil, err
+	}
+
+	// Apply indent to a skeleton of the template, in which each action or
+	// control structure is replaced by a placeholder, rather than to the
+	// template itself, so that an indenting that reflows the text, as IWrap
+	// does, cannot split an action.
+	var skel strings.Builder
+	var holes []parse.Node
+	for _, node := range t.Tree.Root.Nodes {
+		switch n := node.(type) {
+		case *parse.TextNode:
+			skel.Write(n.Text)
+			continue
+		case *parse.IfNode:
+			if text, ok := literalText(n.List); ok && n.ElseList == nil && len(words(text)) != 0 {
+				skel.WriteString(string(beginOpt) + text + string(endOpt))
+				holes = append(holes, node)
+				continue
+			}
+		}
+		skel.WriteRune(hole)
+		holes = append(holes, node)
+	}
+	text := indent.fix(newBlock(skel.String())).String()
+	reflow := indent.reflows()
+
+	const sep = `[^\pL\pN]+` // cf. words
+	var pat strings.Builder
+	var needSep bool
+	gap := -1   // the line breaks a pending wildcard may span, or -1 for none
+	breaks := 0 // the line breaks in the template text since the last word
+	addPattern := func(re string) {
+		if gap >= 0 {
+			pat.WriteString(wildcard(gap + breaks))
+		} else if needSep {
+			pat.WriteString(sep)
+		}
+		pat.WriteString(re)
+		needSep, gap, breaks = true, -1, 0
+	}
+	addWords := func(text string) {
+		ws := words(text)
+		if len(ws) == 0 {
+			breaks += strings.Count(text, "\n")
+			return
+		}
+		breaks += strings.Count(text[:strings.IndexFunc(text, isWordRune)], "\n")
+		for _, w := range ws {
+			addPattern(regexp.QuoteMeta(w))
+		}
+		breaks = strings.Count(text[strings.LastIndexFunc(text, isWordRune):], "\n")
+	}
+	idEnd := -1 // the end of the SPDX identifier in pat, if any
+	for text != "" {
+		i := strings.IndexAny(text, placeholders)
+		if i < 0 {
+			addWords(text)
+			break
+		}
+		addWords(text[:i])
+		r, size := utf8.DecodeRuneInString(text[i:])
+		text = text[i+size:]
+		if r == endOpt {
+			pat.WriteString(`)?`)
+			continue
+		}
+		node := holes[0]
+		holes = holes[1:]
+		switch n := node.(type) {
+		case *parse.ActionNode:
+			if isCall(n, "copyright") {
+				if pat.Len() == 0 {
+					// Elsewhere the phrase follows a separator, but at the
+					// beginning it must not be the tail of a word, as the
+					// "(c)" in a call f(c) would be.
+					addPattern(`(?m:^|[^\pL\pN\n])` + copyrightPhrase)
+				} else {
+					addPattern(copyrightPhrase)
+				}
+				continue
+			} else if isCall(n, "spdx") && lic.SPDX != "" {
+				addPattern(regexp.QuoteMeta(lic.SPDX))
+				idEnd = pat.Len()
+				continue
+			}
+		case *parse.IfNode:
+			if r == beginOpt {
+				if gap < 0 {
+					pat.WriteString(`(?:`)
+					continue // the body follows, and then endOpt
+				}
+				// Following a wildcard, the body is part of it.
+				end := strings.IndexRune(text, endOpt)
+				breaks += strings.Count(text[:end], "\n")
+				text = text[end+utf8.RuneLen(endOpt):]
+			}
+		}
+		if needSep { // no wildcard at the beginning
+			gap = max(gap, nodeGap(node, reflow))
+		}
+	}
+	if idEnd == pat.Len() {
+		// An identifier at the end must not be a prefix of a longer one, as
+		// BSD-2-Clause is of BSD-2-Clause-Views.
+		pat.WriteString(`(?:$|[^-\w.+])`)
+	}
+	return regexp.Compile(`(?i)` + pat.String())
+}
+
+// Placeholders for the parts of a template in the skeleton of a notice
+// pattern; see noticePattern.
+const (
+	hole     = '\uE000' // an action or control structure
+	beginOpt = '\uE001' // the beginning of an optional paragraph
+	endOpt   = '\uE002' // the end of an optional paragraph
+
+	placeholders = string(hole) + string(beginOpt) + string(endOpt)
+)
+
+// nodeGap reports how many line breaks the text rendered for node may span
+// in a notice pattern, beyond those of the surrounding template text, where
+// reflow reports whether the indenting reflows the text. An action spans at
+// most one, as where a long list of authors is wrapped, or a few if reflow is
+// set, and a control structure a few more, as for an optional paragraph.
+// Bounding the gaps keeps a notice from matching words scattered through the
+// code of a file.
+func nodeGap(node parse.Node, reflow bool) int {
+	if _, ok := node.(*parse.ActionNode); ok && !reflow {
+		return 1
+	} else if ok {
+		return 4
+	}
+	return 6
+}
+
+// wildcard returns a pattern that matches a gap between words of a notice
+// that begins and ends with a non-word character and spans at most n line
+// breaks.
+func wildcard(n int) string {
+	return fmt.Sprintf(`[^\pL\pN](?:[^\n]*?(?:\n[^\n]*?){0,%d}[^\pL\pN])?`, n)
+}
+
+// copyrightPhrase matches the phrase rendered by the "copyright" template
+// function, which must include a copyright symbol so that the notice is not
+// confused with another copyright statement, such as one of a third party.
+const copyrightPhrase = `(?:copyright\s*)?(?:\(c\)|©)`
+
+// isCall reports whether n is an action that calls the named function with no
+// arguments, as in {{copyright}}.
+func isCall(n *parse.ActionNode, name string) bool {
+	if len(n.Pipe.Decl) != 0 || len(n.Pipe.Cmds) != 1 || len(n.Pipe.Cmds[0].Args) != 1 {
+		return false
+	}
+	id, ok := n.Pipe.Cmds[0].Args[0].(*parse.IdentifierNode)
+	return ok && id.Ident == name
+}
+
+// literalText returns the text of list if it consists only of literal text.
+func literalText(list *parse.ListNode) (string, bool) {
+	var buf strings.Builder
+	for _, node := range list.Nodes {
+		tn, ok := node.(*parse.TextNode)
+		if !ok {
+			return "", false
+		}
+		buf.Write(tn.Text)
+	}
+	return buf.String(), true
+}
+
+// literalParts returns the literal text segments of t, in order. Segments are
+// split wherever the template has an action or control structure, and the
+// contents of control structures are omitted.
+func literalParts(t *template.Template) []string {
+	var parts []string
+	var cur strings.Builder
+	for _, node := range t.Tree.Root.Nodes {
+		if tn, ok := node.(*parse.TextNode); ok {
+			cur.Write(tn.Text)
+			continue
+		}
+		parts = append(parts, cur.String())
+		cur.Reset()
+	}
+	return append(parts, cur.String())
+}
+
+// dropCopyright removes from s all lines whose first word is "copyright".
+func dropCopyright(s string) string {
+	lines := strings.Split(s, "\n")
+	keep := lines[:0]
+	for _, line := range lines {
+		if ws := words(line); len(ws) != 0 && ws[0] == "copyright" {
+			continue
+		}
+		keep = append(keep, line)
+	}
+	return strings.Join(keep, "\n")
+}
+
+// words splits s into lowercase runs of letters and digits.
+func words(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !isWordRune(r)
+	})
+}
+
+// isWordRune reports whether r is part of a word, as split by words.
+func isWordRune(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }
+
+// shingles returns the multiset of adjacent word pairs in ws.
+func shingles(ws []string) map[[2]string]int {
+	out := make(map[[2]string]int)
+	for i := 1; i < len(ws); i++ {
+		out[[2]string{ws[i-1], ws[i]}]++
+	}
+	return out
+}
+
+// similarity returns the Sørensen-Dice coefficient of multisets a and b.
+func similarity(a, b map[[2]string]int) float64 {
+	var na, nb, common int
+	for k, n := range a {
+		na += n
+		common += min(n, b[k])
+	}
+	for _, n := range b {
+		nb += n
+	}
+	if na+nb == 0 {
+		return 0
+	}
+	return 2 * float64(common) / float64(na+nb)
+}
@@ -0,0 +1,276 @@
+// Copyright (C) 2018 Michael J. Fromberger. All Rights Reserved.
+
+package licenses
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// generic is a license with the generic per-file notice, for testing.
+var generic = &License{Slug: "generic", Text: "Generic license text.", PerFile: PerFileNotice}
+
+var testConfig = &Config{Author: "Alice", Time: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+func TestHasNoticeCopyright(t *testing.T) {
+	slash := IPrefix("// ")
+	tests := []struct {
+		src  string
+		want bool
+	}{
+		{"// Copyright (C) 2024 Alice. All Rights Reserved.\n", true},
+		{"// Copyright (c) 2019 Bob. All rights reserved.\n", true},
+		{"// Copyright © 2019 Bob. All rights reserved.\n", true},
+		{"// © 2019 Bob. All rights reserved.\n", true},
+		{"// (c) 2019 Bob. All rights reserved.\n", true},
+
+		// A mention of the reserved rights alone is not the notice.
+		{"package foo\n// all rights reserved by nobody\n", false},
+		{"# All rights reserved.\n", false},
+
+		// Nor is a copyright statement without a symbol.
+		{"// Copyright 2019 Google LLC. All rights reserved.\n", false},
+
+		// Nor is a "(c)" that ends a word, as in code.
+		{"x := f(c) // All rights reserved.\n", false},
+
+		// Nor are its words scattered through the code and the comments.
+		{"package a\n\nfunc g(c int) int { return h(c) }\n\n// Copyright 2019 Google LLC. All rights reserved.\n", false},
+		{"package a\n\nfunc g(c int) int { return (c) }\n\n// Copyright 2019 Google LLC. All rights reserved.\n", false},
+	}
+	for _, test := range tests {
+		got, err := generic.HasNotice(strings.NewReader(test.src), slash)
+		if err != nil {
+			t.Errorf("HasNotice(%q): unexpected error: %v", test.src, err)
+		} else if got != test.want {
+			t.Errorf("HasNotice(%q): got %v, want %v", test.src, got, test.want)
+		}
+	}
+}
+
+func TestEditBytesIgnoresReservedRights(t *testing.T) {
+	for _, src := range []string{
+		"package foo\n// all rights reserved by nobody\n",
+		"package a\n\nfunc g(c int) int { return (c) }\n\n// Copyright 2019 Google LLC. All rights reserved.\n",
+	} {
+		got, err := generic.EditBytes([]byte(src), testConfig, IPrefix("// "))
+		if err != nil {
+			t.Fatalf("EditBytes(%q): unexpected error: %v", src, err)
+		}
+		want := "// Copyright (C) 2024 Alice. All Rights Reserved.\n\n" + src
+		if string(got) != want {
+			t.Errorf("EditBytes: got %q, want %q", got, want)
+		}
+	}
+}
+
+func TestHasNoticeOptionalParagraph(t *testing.T) {
+	// A control structure may span several lines of the notice.
+	lic := &License{Slug: "test", PerFile: `
+{{copyright}} {{date "2006"}} {{authors}}
+{{- if .Program}}
+
+This file is part of {{.Program}}.
+{{- end}}
+
+{{or .Program "This program"}} is free software.
+`}
+	slash := IPrefix("// ")
+	for _, program := range []string{"", "Frobnicator"} {
+		cfg := *testConfig
+		cfg.Program = program
+		edited, err := lic.EditBytes([]byte("package a\n"), &cfg, slash)
+		if err != nil {
+			t.Fatalf("EditBytes(%q): unexpected error: %v", program, err)
+		}
+		if ok, err := lic.HasNotice(strings.NewReader(string(edited)), slash); err != nil || !ok {
+			t.Errorf("HasNotice(%q): got (%v, %v), want true", edited, ok, err)
+		}
+	}
+}
+
+func TestRemoveNoticeKeepsThirdPartyCopyright(t *testing.T) {
+	const src = "// Copyright 2019 Google LLC. All rights reserved.\n\npackage foo\n"
+	if got, err := generic.RemoveNotice([]byte(src), IPrefix("// ")); !errors.Is(err, ErrNoNotice) {
+		t.Errorf("RemoveNotice: got (%q, %v), want %v", got, err, ErrNoNotice)
+	}
+}
+
+func TestRemoveNoticeMarkers(t *testing.T) {
+	// The lines of comment markers around the notice are removed with it, but
+	// not other lines without words.
+	sstar := IComment("/*", " * ", " */")
+	rule := strings.Repeat("#", 60)
+	tests := []struct {
+		indent    Indenting
+		src, want string
+	}{
+		{IPrefix("# "), "# " + notice + "\n---\nkey: value\n", "---\nkey: value\n"},
+		{sstar, "/*\n * " + notice + "\n */\n{\n  \"key\": 1\n}\n", "{\n  \"key\": 1\n}\n"},
+		{sstar, "/* Package a. */\n/*\n * " + notice + "\n */\n\npackage a\n", "/* Package a. */\npackage a\n"},
+		{IBox("#", 60), rule + "\n# " + notice + " #\n" + rule + "\n\nkey = 1\n", "key = 1\n"},
+	}
+	for _, test := range tests {
+		got, err := generic.RemoveNotice([]byte(test.src), test.indent)
+		if err != nil {
+			t.Errorf("RemoveNotice(%q): unexpected error: %v", test.src, err)
+		} else if string(got) != test.want {
+			t.Errorf("RemoveNotice(%q): got %q, want %q", test.src, got, test.want)
+		}
+	}
+}
+
+func TestRemoveAppendedNotice(t *testing.T) {
+	cfg := *testConfig
+	cfg.AtEnd = true
+	sstar := IComment("/*", " * ", " */")
+	tests := []struct {
+		indent Indenting
+		src    string
+	}{
+		{IPrefix("// "), "package a\n"},
+		{IPrefix("// "), "\xef\xbb\xbfpackage a\n"},
+		{IPrefix("-- "), "SELECT 1;\r\nSELECT 2;\r\n"},
+		{sstar, "a { color: red; }\n"},
+		{sstar, ""},
+	}
+	for _, test := range tests {
+		edited, err := generic.EditBytes([]byte(test.src), &cfg, test.indent)
+		if err != nil {
+			t.Fatalf("EditBytes(%q): unexpected error: %v", test.src, err)
+		}
+		got, err := generic.RemoveNotice(edited, test.indent)
+		if err != nil {
+			t.Errorf("RemoveNotice(%q): unexpected error: %v", edited, err)
+		} else if string(got) != test.src {
+			t.Errorf("RemoveNotice(%q): got %q, want %q", edited, got, test.src)
+		}
+	}
+
+	// A notice in the middle of the file is neither at its beginning nor at
+	// its end, and is not removed.
+	const src = "package a\n\n" + slashNotice + "\nvar x = 1\n"
+	if got, err := generic.RemoveNotice([]byte(src), IPrefix("// ")); !errors.Is(err, ErrNoNotice) {
+		t.Errorf("RemoveNotice(%q): got (%q, %v), want %v", src, got, err, ErrNoNotice)
+	}
+}
+
+func TestNotationRoundTrip(t *testing.T) {
+	for _, notation := range []string{"", "Copyright ©", "©", "(c)"} {
+		cfg := *testConfig
+		cfg.Notation = notation
+		const src = "package foo\n"
+		edited, err := generic.EditBytes([]byte(src), &cfg, IPrefix("// "))
+		if err != nil {
+			t.Fatalf("EditBytes(%q): unexpected error: %v", notation, err)
+		}
+		got, err := generic.RemoveNotice(edited, IPrefix("// "))
+		if err != nil {
+			t.Fatalf("RemoveNotice(%q): unexpected error: %v", notation, err)
+		} else if string(got) != src {
+			t.Errorf("RemoveNotice(%q): got %q, want %q", notation, got, src)
+		}
+	}
+}
+
+func TestWrappedNotice(t *testing.T) {
+	long := &License{Slug: "long", PerFile: `
+{{copyright}} {{date "2006"}} {{authors}}. This file is part of a project whose
+per-file notice runs well past the width of a line, so that it must be wrapped
+to fit. Use of this source code is governed by the terms in the LICENSE file.
+`}
+	tests := []struct {
+		lic   *License
+		width int
+	}{
+		{generic, 30},
+		{long, 72},
+	}
+	const src = "package a\n"
+	for _, test := range tests {
+		indent := IWrap(IPrefix("// "), test.width)
+		edited, err := test.lic.EditBytes([]byte(src), testConfig, indent)
+		if err != nil {
+			t.Fatalf("EditBytes(%s, %d): unexpected error: %v", test.lic.Slug, test.width, err)
+		}
+		for _, line := range strings.Split(string(edited), "\n") {
+			if len(line) > test.width {
+				t.Errorf("EditBytes(%s, %d): line %q is too long", test.lic.Slug, test.width, line)
+			}
+		}
+		if ok, err := test.lic.HasNotice(strings.NewReader(string(edited)), indent); err != nil || !ok {
+			t.Errorf("HasNotice(%q): got (%v, %v), want true", edited, ok, err)
+		}
+		if got, err := test.lic.RemoveNotice(edited, indent); err != nil || string(got) != src {
+			t.Errorf("RemoveNotice(%q): got (%q, %v), want %q", edited, got, err, src)
+		}
+	}
+}
+
+func TestHasNoticeSPDX(t *testing.T) {
+	slash := IPrefix("// ")
+	tests := []struct {
+		spdx, src string
+		want      bool
+	}{
+		{"MIT", "// SPDX-License-Identifier: MIT\n", true},
+		{"MIT", "// spdx-license-identifier: mit", true},
+		{"MIT", "// SPDX-License-Identifier: Apache-2.0\n", false},
+		{"Apache-2.0", "// SPDX-License-Identifier: Apache-2.0\n", true},
+		{"BSD-2-Clause", "// SPDX-License-Identifier: BSD-2-Clause\n", true},
+		{"BSD-2-Clause", "// SPDX-License-Identifier: BSD-2-Clause-Views\n", false},
+		{"GPL-3.0-or-later", "// SPDX-License-Identifier: LGPL-3.0-or-later\n", false},
+	}
+	for _, test := range tests {
+		lic := &License{Slug: "test", SPDX: test.spdx, PerFile: SPDXNotice}
+		got, err := lic.HasNotice(strings.NewReader(test.src), slash)
+		if err != nil {
+			t.Errorf("HasNotice(%q, %q): unexpected error: %v", test.spdx, test.src, err)
+		} else if got != test.want {
+			t.Errorf("HasNotice(%q, %q): got %v, want %v", test.spdx, test.src, got, test.want)
+		}
+	}
+}
+
+func TestDetectNotices(t *testing.T) {
+	t.Cleanup(Reset)
+	Reset()
+	Register(License{Slug: "one", SPDX: "One", Text: "x", PerFile: PerFileNotice})
+	Register(License{Slug: "two", SPDX: "Two", Text: "x", PerFile: PerFileNotice})
+	Register(License{Slug: "three", Text: "x", PerFile: "Licensed under the terms of three.\n"})
+
+	slugs := func(lics []License) string {
+		var out []string
+		for _, lic := range lics {
+			out = append(out, lic.Slug)
+		}
+		return strings.Join(out, ",")
+	}
+	slash := IPrefix("// ")
+	tests := []struct {
+		src, want string
+	}{
+		{"package foo\n", ""},
+		{"// Licensed under the terms of three.\n", "three"},
+		{"// SPDX-License-Identifier: Two\n// Copyright (C) 2024 Alice. All Rights Reserved.\n", "two"},
+		{"// Copyright (C) 2024 Alice. All Rights Reserved.\n", "one,two"},
+	}
+	for _, test := range tests {
+		lics, err := DetectNotices(strings.NewReader(test.src), slash)
+		if err != nil {
+			t.Errorf("DetectNotices(%q): unexpected error: %v", test.src, err)
+		} else if got := slugs(lics); got != test.want {
+			t.Errorf("DetectNotices(%q): got %q, want %q", test.src, got, test.want)
+		}
+
+		// DetectNotice reports a license only if there is exactly one.
+		lic, err := DetectNotice(strings.NewReader(test.src), slash)
+		if err != nil {
+			t.Errorf("DetectNotice(%q): unexpected error: %v", test.src, err)
+		} else if got := lic != nil; got != (len(lics) == 1) {
+			t.Errorf("DetectNotice(%q): got %+v, want %d candidates", test.src, lic, len(lics))
+		}
+	}
+}
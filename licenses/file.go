@@ -0,0 +1,139 @@
+// Copyright (C) 2018 Michael J. Fromberger. All Rights Reserved.
+
+package licenses
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// rewriteFile replaces the contents of f with the result of calling edit on
+// its current contents, as described by WriteFile. The permissions of f are
+// preserved. If edit reports an error, f is not modified.
+//
+// If backup != "", the original contents of f are written to a file whose name
+// is the name of f with backup appended, after the new contents are staged
+// and before they replace the original.
+func rewriteFile(f *os.File, backup string, edit func([]byte) ([]byte, error)) error {
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	// Seek to the beginning of the old file, so we can copy it fully.
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	src, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+	out, err := edit(src)
+	if err != nil {
+		return err
+	}
+	return writeFile(f.Name(), out, fi.Mode().Perm(), func() error {
+		if backup == "" {
+			return nil
+		}
+		return os.WriteFile(f.Name()+backup, src, fi.Mode().Perm())
+	})
+}
+
+// WriteFile writes data to the file at path with permissions perm, creating
+// it if necessary. Unlike os.WriteFile, it stages the data in a tempfile in
+// the same directory, which then replaces the file, so that the file is never
+// observed partially written. If the tempfile cannot be renamed over the file,
+// as across devices, the data are copied into place instead.
+func WriteFile(path string, data []byte, perm fs.FileMode) error {
+	return writeFile(path, data, perm, nil)
+}
+
+// writeFile writes data to the file at path, as described by WriteFile. If
+// staged != nil, it is called after the data are staged and before they
+// replace the file; if it reports an error, the file is not modified.
+func writeFile(path string, data []byte, perm fs.FileMode, staged func() error) error {
+	// Create the tempfile in the same directory, so that it can be renamed
+	// into place.
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(abs), filepath.Base(abs)+"~*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	// Write the data to tmp with the requested permissions. Sync to ensure
+	// the write is committed, then close and replace the original.
+	err = tmp.Chmod(perm)
+	if err == nil {
+		_, err = tmp.Write(data)
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	cerr := tmp.Close()
+	if err != nil {
+		return err
+	} else if cerr != nil {
+		return cerr
+	}
+	if staged != nil {
+		if err := staged(); err != nil {
+			return err
+		}
+	}
+	return replaceFile(tmp.Name(), path)
+}
+
+// rename is used by replaceFile to move a file into place. It is a variable
+// so the copying fallback can be exercised without crossing devices.
+var rename = os.Rename
+
+// replaceFile replaces the contents of the file at target with those of the
+// file at tmp. It renames tmp over target when possible, but falls back to
+// copying when the two are on different devices. In the latter case, the
+// caller is responsible for removing tmp.
+func replaceFile(tmp, target string) error {
+	err := rename(tmp, target)
+	if err == nil || !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+	return copyFile(tmp, target)
+}
+
+// copyFile overwrites the contents and the permissions of the file at dst
+// with those of the file at src, creating dst if it does not exist.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	fi, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, fi.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	err = out.Chmod(fi.Mode().Perm())
+	if err == nil {
+		_, err = io.Copy(out, in)
+	}
+	if err == nil {
+		err = out.Sync()
+	}
+	cerr := out.Close()
+	if err != nil {
+		return err
+	}
+	return cerr
+}
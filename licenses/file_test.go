@@ -0,0 +1,74 @@
+// Copyright (C) 2018 Michael J. Fromberger. All Rights Reserved.
+
+package licenses
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestEditFileMode(t *testing.T) {
+	for _, copying := range []bool{false, true} {
+		if copying {
+			// Simulate a rename across devices, so that the edited contents
+			// are copied into place instead.
+			rename = func(_, _ string) error { return &os.LinkError{Op: "rename", Err: syscall.EXDEV} }
+			t.Cleanup(func() { rename = os.Rename })
+		}
+		path := filepath.Join(t.TempDir(), "script.sh")
+		if err := os.WriteFile(path, []byte("#!/bin/sh\necho hello\n"), 0755); err != nil {
+			t.Fatalf("Writing file: %v", err)
+		}
+		if err := os.Chmod(path, 0755); err != nil { // in case of a umask
+			t.Fatalf("Chmod: %v", err)
+		}
+		if err := editFile(t, path, IPrefix("# ")); err != nil {
+			t.Fatalf("EditFile (copying=%v): unexpected error: %v", copying, err)
+		}
+		fi, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("Stat: %v", err)
+		}
+		if got, want := fi.Mode(), fs.FileMode(0755); got != want {
+			t.Errorf("EditFile (copying=%v): mode is %v, want %v", copying, got, want)
+		}
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	for _, copying := range []bool{false, true} {
+		if copying {
+			rename = func(_, _ string) error { return &os.LinkError{Op: "rename", Err: syscall.EXDEV} }
+			t.Cleanup(func() { rename = os.Rename })
+		}
+		dir := t.TempDir()
+		old := filepath.Join(dir, "old.txt")
+		if err := os.WriteFile(old, []byte("old contents\n"), 0644); err != nil {
+			t.Fatalf("Writing file: %v", err)
+		}
+		for _, path := range []string{old, filepath.Join(dir, "new.txt")} {
+			if err := WriteFile(path, []byte("new contents\n"), 0600); err != nil {
+				t.Fatalf("WriteFile(%q, copying=%v): unexpected error: %v", path, copying, err)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("Reading file: %v", err)
+			} else if got, want := string(data), "new contents\n"; got != want {
+				t.Errorf("WriteFile(%q, copying=%v): got %q, want %q", path, copying, got, want)
+			}
+			if fi, err := os.Stat(path); err != nil {
+				t.Fatalf("Stat: %v", err)
+			} else if got, want := fi.Mode(), fs.FileMode(0600); got != want {
+				t.Errorf("WriteFile(%q, copying=%v): mode is %v, want %v", path, copying, got, want)
+			}
+		}
+		if ents, err := os.ReadDir(dir); err != nil {
+			t.Fatalf("ReadDir: %v", err)
+		} else if len(ents) != 2 {
+			t.Errorf("WriteFile (copying=%v): got %d files, want 2 (tempfile left behind?)", copying, len(ents))
+		}
+	}
+}
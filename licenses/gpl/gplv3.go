@@ -7,11 +7,14 @@ import "github.com/creachadair/lice/licenses"
 
 func init() {
 	licenses.Register(licenses.License{
-		Name:    "GNU General Public License (GPL) version 3",
-		Slug:    "gplv3",
-		URL:     "https://www.gnu.org/licenses/gpl.html",
-		Text:    v3text,
-		PerFile: v3perFile,
+		Name:     "GNU General Public License (GPL) version 3",
+		Slug:     "gplv3",
+		Aliases:  []string{"gpl", "gpl3"},
+		URL:      "https://www.gnu.org/licenses/gpl.html",
+		SPDX:     "GPL-3.0-or-later",
+		Category: licenses.StrongCopyleft,
+		Text:     v3text,
+		PerFile:  v3perFile,
 	})
 }
 
@@ -635,10 +638,22 @@ reviewing courts shall apply local law that most closely approximates
 an absolute waiver of all civil liability in connection with the
 Program, unless a warranty or assumption of liability accompanies a
 copy of the Program in return for a fee.
-`
 
-const v3perFile = `
-    Copyright (C) {{date "2006"}} {{.Author}}
+                     END OF TERMS AND CONDITIONS
+
+            How to Apply These Terms to Your New Programs
+
+  If you develop a new program, and you want it to be of the greatest
+possible use to the public, the best way to achieve this is to make it
+free software which everyone can redistribute and change under these terms.
+
+  To do so, attach the following notices to the program.  It is safest
+to attach them to the start of each source file to most effectively
+state the exclusion of warranty; and each file should have at least
+the "copyright" line and a pointer to where the full notice is found.
+
+    <one line to give the program's name and a brief idea of what it does.>
+    Copyright (C) <year>  <name of author>
 
     This program is free software: you can redistribute it and/or modify
     it under the terms of the GNU General Public License as published by
@@ -652,4 +667,51 @@ const v3perFile = `
 
     You should have received a copy of the GNU General Public License
     along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+Also add information on how to contact you by electronic and paper mail.
+
+  If the program does terminal interaction, make it output a short
+notice like this when it starts in an interactive mode:
+
+    <program>  Copyright (C) <year>  <name of author>
+    This program comes with ABSOLUTELY NO WARRANTY; for details type ` + "`show w'" + `.
+    This is free software, and you are welcome to redistribute it
+    under certain conditions; type ` + "`show c'" + ` for details.
+
+The hypothetical commands ` + "`show w' and `show c'" + ` should show the appropriate
+parts of the General Public License.  Of course, your program's commands
+might be different; for a GUI interface, you would use an "about box".
+
+  You should also get your employer (if you work as a programmer) or school,
+if any, to sign a "copyright disclaimer" for the program, if necessary.
+For more information on this, and how to apply and follow the GNU GPL, see
+<https://www.gnu.org/licenses/>.
+
+  The GNU General Public License does not permit incorporating your program
+into proprietary programs.  If your program is a subroutine library, you
+may consider it more useful to permit linking proprietary applications with
+the library.  If this is what you want to do, use the GNU Lesser General
+Public License instead of this License.  But first, please read
+<https://www.gnu.org/licenses/why-not-lgpl.html>.
+`
+
+const v3perFile = `
+    Copyright (C) {{date "2006"}} {{authors}}
+    {{- if .Program}}
+
+    This file is part of {{.Program}}.
+    {{- end}}
+
+    {{or .Program "This program"}} is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    {{or .Program "This program"}} is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with {{or .Program "this program"}}.  If not, see <https://www.gnu.org/licenses/>.
 `
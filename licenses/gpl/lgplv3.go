@@ -6,11 +6,14 @@ import "github.com/creachadair/lice/licenses"
 
 func init() {
 	licenses.Register(licenses.License{
-		Name:    "GNU Lesser General Public License (LGPL) version 3",
-		Slug:    "lgplv3",
-		URL:     "https://www.gnu.org/licenses/lgpl.html",
-		Text:    lv3text,
-		PerFile: lv3perFile,
+		Name:     "GNU Lesser General Public License (LGPL) version 3",
+		Slug:     "lgplv3",
+		Aliases:  []string{"lgpl", "lgpl3"},
+		URL:      "https://www.gnu.org/licenses/lgpl.html",
+		SPDX:     "LGPL-3.0-or-later",
+		Category: licenses.WeakCopyleft,
+		Text:     lv3text,
+		PerFile:  lv3perFile,
 	})
 }
 
@@ -183,18 +186,22 @@ Library.
 `
 
 const lv3perFile = `
-    Copyright (C) {{date "2006"}} {{.Author}}
+    Copyright (C) {{date "2006"}} {{authors}}
+    {{- if .Program}}
 
-    This program is free software: you can redistribute it and/or modify it
+    This file is part of {{.Program}}.
+    {{- end}}
+
+    {{or .Program "This program"}} is free software: you can redistribute it and/or modify it
     under the terms of the GNU Lesser General Public License as published by
     the Free Software Foundation, either version 3 of the License, or (at your
     option) any later version.
 
-    This program is distributed in the hope that it will be useful, but WITHOUT
+    {{or .Program "This program"}} is distributed in the hope that it will be useful, but WITHOUT
     ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
     FITNESS FOR A PARTICULAR PURPOSE.  See the GNU General Public License for
     more details.
 
     You should have received a copy of the GNU Lesser General Public License
-    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+    along with {{or .Program "this program"}}.  If not, see <https://www.gnu.org/licenses/>.
 `
@@ -7,16 +7,19 @@ import "github.com/creachadair/lice/licenses"
 
 func init() {
 	licenses.Register(licenses.License{
-		Name:    "MIT License (Expat)",
-		Slug:    "mit-expat",
-		URL:     "https://directory.fsf.org/wiki/License:Expat",
-		Text:    text,
-		PerFile: licenses.PerFileNotice,
+		Name:     "MIT License (Expat)",
+		Slug:     "mit-expat",
+		Aliases:  []string{"mit", "expat"},
+		URL:      "https://directory.fsf.org/wiki/License:Expat",
+		SPDX:     "MIT",
+		Category: licenses.Permissive,
+		Text:     text,
+		PerFile:  licenses.PerFileNotice,
 	})
 }
 
 const text = `
-Copyright (c) {{date "2006"}} {{.Author}}. All Rights Reserved.
+Copyright (c) {{date "2006"}} {{authors}}. All Rights Reserved.
  
 Permission is hereby granted, free of charge, to any person obtaining a copy of
 this software and associated documentation files (the "Software"), to deal in
@@ -8,11 +8,13 @@ import "github.com/creachadair/lice/licenses"
 
 func init() {
 	licenses.Register(licenses.License{
-		Name:    "Mozilla Public License, v 2.0",
-		Slug:    "mpl2",
-		URL:     "https://www.mozilla.org/en-US/MPL/",
-		Text:    text,
-		PerFile: perFile,
+		Name:     "Mozilla Public License, v 2.0",
+		Slug:     "mpl2",
+		URL:      "https://www.mozilla.org/en-US/MPL/",
+		SPDX:     "MPL-2.0",
+		Category: licenses.WeakCopyleft,
+		Text:     text,
+		PerFile:  perFile,
 	})
 }
 
@@ -397,4 +399,9 @@ const perFile = `
 This Source Code Form is subject to the terms of the Mozilla Public
 License, v. 2.0. If a copy of the MPL was not distributed with this
 file, You can obtain one at https://mozilla.org/MPL/2.0/.
+{{- if .IncompatibleSecondary}}
+
+This Source Code Form is "Incompatible With Secondary Licenses", as
+defined by the Mozilla Public License, v. 2.0.
+{{- end}}
 `
@@ -0,0 +1,247 @@
+// Copyright (C) 2018 Michael J. Fromberger. All Rights Reserved.
+
+package licenses
+
+import (
+	"bytes"
+	"regexp"
+)
+
+// preambles are rules for recognizing text that must remain at the top of a
+// file, in the order it may appear. Each rule returns the offset of the end
+// of its part of the preamble beginning at pos in src, or pos if src does not
+// have that part at pos.
+var preambles = []func(src []byte, pos int) int{
+	skipInterpreter, // #!/bin/sh
+	skipEncoding,    // # -*- coding: utf-8 -*-
+	skipConstraints, // //go:build linux
+	skipPHP,         // <?php
+	skipMarkup,      // <?xml version="1.0"?>
+}
+
+// splitPreamble splits src into a preamble that must remain at the top of the
+// file, and the remainder of the file after it. The preamble includes:
+//
+//   - An interpreter line ("#!...") for a script.
+//   - A Python source encoding declaration on the first or second line.
+//   - Go build constraints ("//go:build" and "// +build" lines), along with
+//     the blank line that must separate them from the package clause.
+//   - A PHP opening tag ("<?php").
+//   - An XML declaration ("<?xml ...?>") and document type declaration
+//     ("<!DOCTYPE ...>") for a markup file.
+func splitPreamble(src []byte) (head, rest []byte) {
+	pos := 0
+	for _, skip := range preambles {
+		pos = skip(src, pos)
+	}
+	return src[:pos], src[pos:]
+}
+
+// skipInterpreter skips an interpreter line at the beginning of src.
+func skipInterpreter(src []byte, pos int) int {
+	if pos == 0 && bytes.HasPrefix(src, []byte("#!")) {
+		return nextLine(src, 0)
+	}
+	return pos
+}
+
+// encodingDecl matches a Python source encoding declaration (PEP 263).
+var encodingDecl = regexp.MustCompile(`^[ \t\f]*#.*?coding[:=][ \t]*[-_.a-zA-Z0-9]+`)
+
+// skipEncoding skips a Python encoding declaration, which must be on the first
+// or second line of the file.
+func skipEncoding(src []byte, pos int) int {
+	if pos != 0 && pos != nextLine(src, 0) {
+		return pos
+	}
+	next := nextLine(src, pos)
+	if encodingDecl.Match(src[pos:next]) {
+		return next
+	}
+	return pos
+}
+
+// skipPHP skips the line containing a PHP opening tag.
+func skipPHP(src []byte, pos int) int {
+	if hasPrefixFold(src[pos:], "<?php") {
+		return nextLine(src, pos)
+	}
+	return pos
+}
+
+// skipMarkup returns the offset of the end of the line containing the end of
+// the XML declaration and document type declaration beginning at pos in src.
+// Either may be omitted. If src has neither at pos, it returns pos.
+func skipMarkup(src []byte, pos int) int {
+	end := pos
+	if bytes.HasPrefix(src[pos:], []byte("<?xml")) {
+		end = skipTo(src, pos, "?>")
+	}
+
+	// A document type declaration may follow the XML declaration after some
+	// whitespace.
+	cur := end
+	for cur < len(src) && isSpace(src[cur]) {
+		cur++
+	}
+	if hasPrefixFold(src[cur:], "<!DOCTYPE") {
+		end = skipTo(src, cur, ">")
+	}
+	return end
+}
+
+// skipTo returns the offset of the beginning of the line after the first
+// occurrence of tag at or after pos in src, or len(src) if there is none.
+func skipTo(src []byte, pos int, tag string) int {
+	i := bytes.Index(src[pos:], []byte(tag))
+	if i < 0 {
+		return len(src)
+	}
+	return nextLine(src, pos+i)
+}
+
+func isSpace(b byte) bool { return b == ' ' || b == '\t' || b == '\r' || b == '\n' }
+
+func hasPrefixFold(s []byte, prefix string) bool {
+	return len(s) >= len(prefix) && bytes.EqualFold(s[:len(prefix)], []byte(prefix))
+}
+
+// skipConstraints returns the offset of the end of the block of Go build
+// constraint lines beginning at pos in src, including any blank lines that
+// follow it. If src does not have build constraints at pos, it returns pos.
+func skipConstraints(src []byte, pos int) int {
+	end := pos
+	for cur := pos; cur < len(src); {
+		next := nextLine(src, cur)
+		line := bytes.TrimSpace(src[cur:next])
+		if isConstraint(line) {
+			end = next
+		} else if len(line) != 0 {
+			break
+		} else if end > pos {
+			end = next // include blank lines after a constraint
+		}
+		cur = next
+	}
+	return end
+}
+
+// isConstraint reports whether line is a Go build constraint comment.
+func isConstraint(line []byte) bool {
+	return bytes.HasPrefix(line, []byte("//go:build ")) ||
+		bytes.HasPrefix(line, []byte("// +build "))
+}
+
+// skipComment returns the length of the comment block at the beginning of
+// src, in the comment syntax of indent. It returns 0 if src does not begin
+// with a comment. For line comments such as "# ...", the block is a run of
+// consecutive commented lines; for block comments such as "/* ... */", it
+// runs through the closing marker.
+func skipComment(src []byte, indent Indenting) int {
+	if indent == nil {
+		return 0
+	}
+	head, tail, first, left, right := indent.probe()
+	open := bytes.TrimSpace([]byte(first))
+	if len(head) != 0 {
+		open = bytes.TrimSpace([]byte(head[0]))
+	}
+	shut := bytes.TrimSpace([]byte(right))
+	if len(tail) != 0 {
+		shut = bytes.TrimSpace([]byte(tail[len(tail)-1]))
+	}
+
+	pos := 0
+	if len(shut) != 0 && !bytes.Equal(open, shut) {
+		// A block comment, which must be closed.
+		text := bytes.TrimLeft(src, " \t\r\n")
+		if len(open) == 0 || !bytes.HasPrefix(text, open) {
+			return 0
+		}
+		at := len(src) - len(text) + len(open)
+		i := bytes.Index(src[at:], shut)
+		if i < 0 {
+			return 0
+		}
+		pos = nextLine(src, at+i)
+	} else {
+		// A run of line comments.
+		marker := bytes.TrimSpace([]byte(left))
+		if len(marker) == 0 {
+			return 0
+		}
+		for pos < len(src) {
+			next := nextLine(src, pos)
+			if !bytes.HasPrefix(bytes.TrimSpace(src[pos:next]), marker) {
+				break
+			}
+			pos = next
+		}
+	}
+	return pos
+}
+
+// skipBlankLines returns the offset of the first line at or after the line
+// beginning at pos in src that is not blank, or len(src) if there is none.
+func skipBlankLines(src []byte, pos int) int {
+	for pos < len(src) {
+		next := nextLine(src, pos)
+		if !isBlank(src[pos:next]) {
+			break
+		}
+		pos = next
+	}
+	return pos
+}
+
+// trimBlankLines returns the offset in src where the run of blank lines at its
+// end begins, or len(src) if it does not end with a blank line.
+func trimBlankLines(src []byte) int {
+	end := len(src)
+	for end > 0 {
+		start := lineStart(src, end-1)
+		if !isBlank(src[start:end]) {
+			break
+		}
+		end = start
+	}
+	return end
+}
+
+// lineStart returns the offset of the beginning of the line containing offset
+// pos in src.
+func lineStart(src []byte, pos int) int {
+	return bytes.LastIndexByte(src[:pos], '\n') + 1
+}
+
+// nextLine returns the offset of the beginning of the line after the one
+// containing offset pos in src, or len(src) if there is none.
+func nextLine(src []byte, pos int) int {
+	if i := bytes.IndexByte(src[pos:], '\n'); i >= 0 {
+		return pos + i + 1
+	}
+	return len(src)
+}
+
+// utf8BOM is the encoding of a Unicode byte-order mark in UTF-8.
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// splitBOM splits a leading UTF-8 byte-order mark from src, if it has one.
+func splitBOM(src []byte) (bom, rest []byte) {
+	if bytes.HasPrefix(src, utf8BOM) {
+		return src[:len(utf8BOM)], src[len(utf8BOM):]
+	}
+	return nil, src
+}
+
+// usesCRLF reports whether most of the lines in src end with CRLF.
+func usesCRLF(src []byte) bool {
+	crlf := bytes.Count(src, []byte("\r\n"))
+	return crlf > bytes.Count(src, []byte("\n"))-crlf
+}
+
+// toCRLF returns a copy of src with all line endings converted to CRLF.
+func toCRLF(src []byte) []byte {
+	lf := bytes.ReplaceAll(src, []byte("\r\n"), []byte("\n"))
+	return bytes.ReplaceAll(lf, []byte("\n"), []byte("\r\n"))
+}
@@ -0,0 +1,102 @@
+// Copyright (C) 2018 Michael J. Fromberger. All Rights Reserved.
+
+package licenses
+
+import "testing"
+
+func TestShebang(t *testing.T) {
+	checkEdits(t, testConfig, IPrefix("# "), []editTest{
+		{"bash",
+			"#!/bin/bash\necho hello\n",
+			"#!/bin/bash\n# " + notice + "\n\necho hello\n"},
+		{"env",
+			"#!/usr/bin/env python3\n\nprint('hello')\n",
+			"#!/usr/bin/env python3\n# " + notice + "\n\nprint('hello')\n"},
+		{"only",
+			"#!/bin/sh",
+			"#!/bin/sh\n# " + notice + "\n"},
+	})
+}
+
+func TestBuildConstraints(t *testing.T) {
+	checkEdits(t, testConfig, IPrefix("// "), []editTest{
+		{"go:build",
+			"//go:build linux\n\npackage a\n",
+			"//go:build linux\n\n// " + notice + "\n\npackage a\n"},
+		{"+build",
+			"// +build linux darwin\n\npackage a\n",
+			"// +build linux darwin\n\n// " + notice + "\n\npackage a\n"},
+		{"both",
+			"//go:build linux || darwin\n// +build linux darwin\n\npackage a\n",
+			"//go:build linux || darwin\n// +build linux darwin\n\n// " + notice + "\n\npackage a\n"},
+	})
+}
+
+func TestMarkupDeclaration(t *testing.T) {
+	checkEdits(t, testConfig, IComment("<!--", "   ", "  -->"), []editTest{
+		{"xml",
+			"<?xml version=\"1.0\"?>\n<root/>\n",
+			"<?xml version=\"1.0\"?>\n<!--\n   " + notice + "\n  -->\n\n<root/>\n"},
+		{"doctype",
+			"<!DOCTYPE html>\n<html></html>\n",
+			"<!DOCTYPE html>\n<!--\n   " + notice + "\n  -->\n\n<html></html>\n"},
+		{"xml+doctype",
+			"<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<!DOCTYPE html\n  PUBLIC \"-//W3C//DTD XHTML 1.0 Strict//EN\">\n<html/>\n",
+			"<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<!DOCTYPE html\n  PUBLIC \"-//W3C//DTD XHTML 1.0 Strict//EN\">\n<!--\n   " + notice + "\n  -->\n\n<html/>\n"},
+	})
+}
+
+func TestLanguageDeclarations(t *testing.T) {
+	checkEdits(t, testConfig, IPrefix("// "), []editTest{
+		{"php",
+			"<?php\necho 'hello';\n",
+			"<?php\n// " + notice + "\n\necho 'hello';\n"},
+	})
+	checkEdits(t, testConfig, IPrefix("# "), []editTest{
+		{"coding line 1",
+			"# -*- coding: utf-8 -*-\nprint('hello')\n",
+			"# -*- coding: utf-8 -*-\n# " + notice + "\n\nprint('hello')\n"},
+		{"coding line 2",
+			"#!/usr/bin/env python\n# -*- coding: latin-1 -*-\nprint('hello')\n",
+			"#!/usr/bin/env python\n# -*- coding: latin-1 -*-\n# " + notice + "\n\nprint('hello')\n"},
+		{"coding line 3",
+			"#!/usr/bin/env python\n\n# -*- coding: latin-1 -*-\nprint('hello')\n",
+			"#!/usr/bin/env python\n# " + notice + "\n\n# -*- coding: latin-1 -*-\nprint('hello')\n"},
+	})
+}
+
+func TestLineEndings(t *testing.T) {
+	const src = "#!/bin/sh\r\necho one\r\necho two\r\n"
+	block := "/*\r\n * " + notice + "\r\n */\r\n"
+	checkEdits(t, testConfig, IComment("/*", " * ", " */"), []editTest{
+		{"head", src, "#!/bin/sh\r\n" + block + "\r\necho one\r\necho two\r\n"},
+	})
+	cfg := *testConfig
+	cfg.AtEnd = true
+	checkEdits(t, &cfg, IComment("/*", " * ", " */"), []editTest{
+		{"end", src, src + "\r\n" + block},
+	})
+
+	// A file whose lines mostly end in LF keeps LF endings throughout.
+	checkEdits(t, testConfig, IPrefix("# "), []editTest{
+		{"mostly LF", "a\nb\nc\r\n", "# " + notice + "\n\na\nb\nc\r\n"},
+	})
+}
+
+func TestByteOrderMark(t *testing.T) {
+	const bom = "\xef\xbb\xbf"
+	checkEdits(t, testConfig, IPrefix("// "), []editTest{
+		{"head", bom + "package a\n",
+			bom + "// " + notice + "\n\npackage a\n"},
+		{"head/constraint", bom + "//go:build linux\n\npackage a\n",
+			bom + "//go:build linux\n\n// " + notice + "\n\npackage a\n"},
+		{"only", bom,
+			bom + "// " + notice + "\n"},
+	})
+	cfg := *testConfig
+	cfg.AtEnd = true
+	checkEdits(t, &cfg, IPrefix("// "), []editTest{
+		{"end", bom + "package a\n",
+			bom + "package a\n\n// " + notice + "\n"},
+	})
+}
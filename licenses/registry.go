@@ -2,34 +2,109 @@
 
 package licenses
 
-import "log"
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+)
 
+// A registry is a collection of licenses indexed by slug and alias. The
+// fetch, has, count, suggest, all, insert, remove, and reset methods are safe
+// for concurrent use; the others require the caller to hold mu.
 type registry struct {
-	known []License // ordered by slug
+	mu    sync.RWMutex
+	known []License         // ordered by normalized slug
+	alias map[string]string // normalized alias → normalized slug
 }
 
-func (r *registry) fetch(slug string) *License {
-	if i, ok := r.lookup(slug); ok {
+// normalize returns the registry key for a slug or alias.
+func normalize(s string) string { return strings.ToLower(s) }
+
+func (r *registry) fetch(name string) *License {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if i, ok := r.resolve(name); ok {
 		out := r.known[i]
 		return &out
 	}
 	return nil
 }
 
-func (r *registry) visit(f func(License)) {
+// suggest returns the slugs and aliases whose edit distance from name is at
+// most maxDist, ordered by increasing distance.
+func (r *registry) suggest(name string, maxDist int) []string {
+	type match struct {
+		name string
+		dist int
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	key := normalize(name)
+	var ms []match
+	try := func(cand string) {
+		if d := editDistance(key, normalize(cand)); d <= maxDist {
+			ms = append(ms, match{cand, d})
+		}
+	}
 	for _, lic := range r.known {
-		f(lic)
+		try(lic.Slug)
+		for _, alias := range lic.Aliases {
+			try(alias)
+		}
+	}
+	sort.SliceStable(ms, func(i, j int) bool { return ms[i].dist < ms[j].dist })
+	out := make([]string, len(ms))
+	for i, m := range ms {
+		out[i] = m.name
 	}
+	return out
 }
 
-func (r *registry) lookup(slug string) (int, bool) {
+func (r *registry) has(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.resolve(name)
+	return ok
+}
+
+func (r *registry) count() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.known)
+}
+
+func (r *registry) all() []License {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]License, len(r.known))
+	copy(out, r.known)
+	return out
+}
+
+// resolve returns the index of the license whose slug or alias matches name,
+// ignoring case.
+func (r *registry) resolve(name string) (int, bool) {
+	key := normalize(name)
+	if slug, ok := r.alias[key]; ok {
+		key = slug
+	}
+	return r.lookup(key)
+}
+
+// lookup returns the index of the license with the given normalized slug, or
+// the index where it would be inserted if it is not present.
+func (r *registry) lookup(key string) (int, bool) {
+	// The search window is the half-open range [i, j), so setting j = m when
+	// key < cur excludes m and the window strictly shrinks on every pass.
 	i, j := 0, len(r.known)
 	for i < j {
 		m := (i + j) / 2
-		cur := r.known[m].Slug
-		if slug == cur {
+		cur := normalize(r.known[m].Slug)
+		if key == cur {
 			return m, true
-		} else if slug < cur {
+		} else if key < cur {
 			j = m
 		} else {
 			i = m + 1
@@ -38,32 +113,182 @@ func (r *registry) lookup(slug string) (int, bool) {
 	return i, false
 }
 
-func (r *registry) insert(lic License) bool {
-	i, ok := r.lookup(lic.Slug)
+func (r *registry) insert(lic License) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := normalize(lic.Slug)
+	i, ok := r.lookup(key)
 	if ok {
+		return fmt.Errorf("duplicate registrations for slug %q", lic.Slug)
+	} else if _, ok := r.alias[key]; ok {
+		return fmt.Errorf("slug %q is already registered as an alias", lic.Slug)
+	}
+	aliases := make(map[string]bool)
+	for _, name := range lic.Aliases {
+		akey := normalize(name)
+		if akey == key {
+			continue // an alias for the slug itself is harmless
+		} else if _, ok := r.lookup(akey); ok {
+			return fmt.Errorf("alias %q is already registered as a slug", name)
+		} else if _, ok := r.alias[akey]; ok || aliases[akey] {
+			return fmt.Errorf("duplicate registrations for alias %q", name)
+		}
+		aliases[akey] = true
+	}
+
+	// Grow by one, shift the tail up, and place the new entry. Each step copies
+	// from a position that has not yet been overwritten, so this is safe even
+	// when append reuses the existing backing array.
+	r.known = append(r.known, License{})
+	copy(r.known[i+1:], r.known[i:])
+	r.known[i] = lic
+	if len(aliases) != 0 && r.alias == nil {
+		r.alias = make(map[string]string)
+	}
+	for akey := range aliases {
+		r.alias[akey] = key
+	}
+	return nil
+}
+
+func (r *registry) remove(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i, ok := r.resolve(name)
+	if !ok {
 		return false
 	}
-	r.known = append(r.known[:i], append([]License{lic}, r.known[i:]...)...)
+	key := normalize(r.known[i].Slug)
+	for akey, slug := range r.alias {
+		if slug == key {
+			delete(r.alias, akey)
+		}
+	}
+	r.known = append(r.known[:i], r.known[i+1:]...)
 	return true
 }
 
+func (r *registry) reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.known, r.alias = nil, nil
+}
+
 var global = new(registry)
 
-// Register records a new license in the registry, using its slug as a
-// key. This function will panic if the license slug is empty, or if the slug
-// is already registered to a different license.
+// Register records a new license in the registry, using its slug and aliases
+// as keys. It is safe to call Register concurrently with other functions in
+// this package, although licenses are usually registered during init. Keys
+// are compared without regard to case. This function will panic if the
+// license slug is empty, or if the slug or any of its aliases is already
+// registered to a different license.
 func Register(lic License) {
+	lic.cache = new(templateCache)
 	if lic.Slug == "" {
 		log.Panic("empty license slug")
-	} else if !global.insert(lic) {
-		log.Panicf("duplicate registrations for slug %q", lic.Slug)
+	} else if err := global.insert(lic); err != nil {
+		log.Panic(err)
+	}
+}
+
+// Unregister removes the license with the specified slug or alias from the
+// registry, along with all its aliases. It reports whether a license was
+// removed. After a license is removed, a different license may be registered
+// with the same slug.
+func Unregister(name string) bool { return global.remove(name) }
+
+// Reset removes all licenses from the registry. This is mainly useful for
+// isolating tests that register their own licenses.
+func Reset() { global.reset() }
+
+// Lookup returns the license information for the specified slug or alias, or
+// nil if no such license is registered. The name is matched without regard to
+// case.
+func Lookup(name string) *License { return global.fetch(name) }
+
+// ListByCategory calls f for each registered license in the given category.
+// Licenses are visited in lexicographic order by slug.
+func ListByCategory(cat Category, f func(License)) {
+	List(func(lic License) {
+		if lic.Category == cat {
+			f(lic)
+		}
+	})
+}
+
+// Search returns the registered licenses whose name or slug contains query,
+// ignoring case, in lexicographic order by slug.
+func Search(query string) []License {
+	query = strings.ToLower(query)
+	var out []License
+	List(func(lic License) {
+		if strings.Contains(strings.ToLower(lic.Name), query) ||
+			strings.Contains(strings.ToLower(lic.Slug), query) {
+			out = append(out, lic)
+		}
+	})
+	return out
+}
+
+// Suggest returns the registered slugs and aliases that are close to name, as
+// measured by edit distance, with the closest matches first. It returns nil if
+// there are no close matches.
+func Suggest(name string) []string {
+	maxDist := 2
+	if n := len(name) / 3; n > maxDist {
+		maxDist = n
 	}
+	return global.suggest(name, maxDist)
 }
 
-// Lookup returns the license information for the specified slug, or nil if no
-// such license is registered.
-func Lookup(slug string) *License { return global.fetch(slug) }
+// Has reports whether a license is registered with the specified slug or
+// alias, ignoring case.
+func Has(name string) bool { return global.has(name) }
+
+// Count returns the number of registered licenses.
+func Count() int { return global.count() }
+
+// MustLookup returns the license information for the specified slug or alias.
+// It panics if no such license is registered. This is intended for programs
+// that use a fixed, known-good slug; use Lookup for dynamic input.
+func MustLookup(name string) License {
+	lic := Lookup(name)
+	if lic == nil {
+		log.Panicf("licenses: no license registered for %q", name)
+	}
+	return *lic
+}
+
+// Licenses returns a copy of the registered licenses, in lexicographic order
+// by slug.
+func Licenses() []License { return global.all() }
 
 // List calls f for each registered license.  Licenses are visited in
 // lexicographic order by slug.
-func List(f func(License)) { global.visit(f) }
+func List(f func(License)) {
+	for _, lic := range Licenses() {
+		f(lic)
+	}
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,106 @@
+// Copyright (C) 2018 Michael J. Fromberger. All Rights Reserved.
+
+package licenses
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestConcurrentRegister(t *testing.T) {
+	t.Cleanup(Reset)
+	Reset()
+
+	const n = 50
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			Register(License{Slug: fmt.Sprintf("lic%02d", i), Aliases: []string{fmt.Sprintf("alias%02d", i)}})
+		}()
+		go func() {
+			defer wg.Done()
+			// The license may or may not be registered yet, but the lookup must
+			// not race with the insertion.
+			if lic := Lookup(fmt.Sprintf("alias%02d", i)); lic != nil && lic.Slug != fmt.Sprintf("lic%02d", i) {
+				t.Errorf("Lookup(alias%02d): got slug %q", i, lic.Slug)
+			}
+			Licenses()
+		}()
+	}
+	wg.Wait()
+
+	if got := Count(); got != n {
+		t.Errorf("Count: got %d, want %d", got, n)
+	}
+	for i := range n {
+		slug := fmt.Sprintf("lic%02d", i)
+		if lic := Lookup(slug); lic == nil || lic.Slug != slug {
+			t.Errorf("Lookup(%q): got %+v, want slug %q", slug, lic, slug)
+		}
+	}
+}
+
+func TestRegisterOrder(t *testing.T) {
+	t.Cleanup(Reset)
+
+	// Insert in orders that exercise every position in the slice: ascending
+	// (append at the end), descending (insert at the front), and alternating
+	// from both ends toward the middle.
+	slugs := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+	var inward []string
+	for i, j := 0, len(slugs)-1; i <= j; i, j = i+1, j-1 {
+		inward = append(inward, slugs[j])
+		if i != j {
+			inward = append(inward, slugs[i])
+		}
+	}
+	orders := map[string][]string{
+		"ascending":  slugs,
+		"descending": {"h", "g", "f", "e", "d", "c", "b", "a"},
+		"inward":     inward,
+		"shuffled":   {"d", "h", "a", "f", "c", "g", "b", "e"},
+	}
+	for name, order := range orders {
+		Reset()
+		for _, slug := range order {
+			// Give each license a distinct name so corruption is visible.
+			Register(License{Slug: slug, Name: "License " + slug})
+		}
+		got := Licenses()
+		if len(got) != len(slugs) {
+			t.Errorf("%s: got %d licenses, want %d", name, len(got), len(slugs))
+			continue
+		}
+		for i, lic := range got {
+			if lic.Slug != slugs[i] || lic.Name != "License "+slugs[i] {
+				t.Errorf("%s: license %d is %q (%q), want %q", name, i, lic.Slug, lic.Name, slugs[i])
+			}
+		}
+	}
+}
+
+func TestLookupBeforeAll(t *testing.T) {
+	t.Cleanup(Reset)
+	Reset()
+	for _, slug := range []string{"mit", "bsd", "gpl"} {
+		Register(License{Slug: slug})
+	}
+	// Each of these sorts before every registered slug, so the search narrows
+	// to the front of the slice.
+	for _, name := range []string{"a", "aaa", "BSA", ""} {
+		done := make(chan *License)
+		go func() { done <- Lookup(name) }()
+		select {
+		case lic := <-done:
+			if lic != nil {
+				t.Errorf("Lookup(%q): got %q, want nil", name, lic.Slug)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Lookup(%q) did not terminate", name)
+		}
+	}
+}
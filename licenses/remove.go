@@ -0,0 +1,118 @@
+// Copyright (C) 2018 Michael J. Fromberger. All Rights Reserved.
+
+package licenses
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+)
+
+// ErrNoNotice is reported by RemoveNotice when the file does not have the
+// per-file notice for the license.
+var ErrNoNotice = errors.New("file does not have a license notice")
+
+// RemoveNotice returns a copy of src with the per-file notice for the license
+// removed from its beginning, along with any blank lines following it, or from
+// its end, as appended by EditBytes with Config.AtEnd, along with any blank
+// lines preceding it. This reverses the effect of EditBytes: The notice is
+// matched as by HasNotice, and any preamble such as an interpreter line or
+// build constraints is kept, as is a comment block above the notice, as
+// inserted with Config.AfterComment.
+//
+// If src neither begins nor ends with the notice, RemoveNotice reports
+// ErrNoNotice.
+func (lic *License) RemoveNotice(src []byte, indent Indenting) ([]byte, error) {
+	if lic == nil || lic.PerFile == "" {
+		return nil, ErrNoNotice
+	}
+	re, err := lic.noticePattern(indent)
+	if err != nil {
+		return nil, err
+	}
+	bom, body := splitBOM(src)
+	head, rest := splitPreamble(body)
+	if loc := re.FindIndex(rest[:min(len(rest), maxNoticeOffset)]); loc != nil {
+		// The notice must be at the top of the file, after the preamble and
+		// any comment block that EditBytes may have kept above it.
+		start, end := noticeLines(rest, loc, indent)
+		keep := skipComment(rest[:start], indent)
+		if skipBlankLines(rest, keep) >= start {
+			end = skipBlankLines(rest, end)
+			out := make([]byte, 0, len(src)-end+start)
+			out = append(out, bom...)
+			out = append(out, head...)
+			out = append(out, rest[:keep]...)
+			return append(out, rest[end:]...), nil
+		}
+	}
+
+	// Otherwise, the notice must be at the end of the file, with nothing but
+	// blank lines after it.
+	pos := max(0, len(body)-maxNoticeOffset)
+	all := re.FindAllIndex(body[pos:], -1)
+	if len(all) == 0 {
+		return nil, ErrNoNotice
+	}
+	loc := all[len(all)-1]
+	start, end := noticeLines(body, []int{pos + loc[0], pos + loc[1]}, indent)
+	if skipBlankLines(body, end) < len(body) {
+		return nil, ErrNoNotice
+	}
+	keep := trimBlankLines(body[:start])
+	out := make([]byte, 0, len(bom)+keep)
+	out = append(out, bom...)
+	return append(out, body[:keep]...), nil
+}
+
+// noticeLines extends loc, the location of a match for a notice pattern in
+// src, to whole lines, including the lines that indent adds above and below
+// the text, such as "/*" and "*/", but not other lines without words, such as
+// a "---" that begins a YAML document. It returns the offsets of the
+// beginning and the end of the lines.
+func noticeLines(src []byte, loc []int, indent Indenting) (start, end int) {
+	above, below, _, _, _ := indent.probe()
+	start, end = lineStart(src, loc[0]), nextLine(src, loc[1]-1)
+	for range above {
+		if start == 0 {
+			break
+		}
+		prev := lineStart(src, start-1)
+		if !isMarkup(src[prev:start], indent) {
+			break
+		}
+		start = prev
+	}
+	for range below {
+		next := nextLine(src, end)
+		if end == len(src) || !isMarkup(src[end:next], indent) {
+			break
+		}
+		end = next
+	}
+	return start, end
+}
+
+// RemoveNoticeFile removes the per-file notice for the license from f, as
+// described by RemoveNotice. The permissions of f are preserved. If f does not
+// have the notice, it is not modified and RemoveNoticeFile reports
+// ErrNoNotice.
+func (lic *License) RemoveNoticeFile(f *os.File, indent Indenting) error {
+	return rewriteFile(f, "", func(src []byte) ([]byte, error) {
+		return lic.RemoveNotice(src, indent)
+	})
+}
+
+// isBlank reports whether line consists only of whitespace.
+func isBlank(line []byte) bool { return len(bytes.TrimSpace(line)) == 0 }
+
+// isMarkup reports whether line consists only of markers that indent adds,
+// such as "/*" or "*/", with no text of its own.
+func isMarkup(line []byte, indent Indenting) bool {
+	if isBlank(line) {
+		return false
+	}
+	b := indent.unfix(newBlock(string(bytes.TrimSpace(line))))
+	return strings.TrimSpace(b.String()) == ""
+}
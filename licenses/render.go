@@ -0,0 +1,112 @@
+// Copyright (C) 2018 Michael J. Fromberger. All Rights Reserved.
+
+package licenses
+
+import (
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// A Renderer renders the texts of a license with fixed settings, for use when
+// the same license is applied to many files. The templates of the license are
+// executed once, when the Renderer is created, rather than for each file. A
+// Renderer is safe for concurrent use by multiple goroutines.
+type Renderer struct {
+	lic    *License
+	cfg    Config
+	text   string // the rendered license text
+	notice string // the rendered per-file notice, not yet indented
+
+	mu       sync.Mutex
+	patterns map[string]*regexp.Regexp // notice patterns, by indented template
+}
+
+// Compile returns a Renderer for lic with the settings in c. It reports an
+// error if any of the templates of lic cannot be rendered. Later changes to
+// lic or c do not affect the Renderer. If c.Time is zero, the Renderer uses
+// the time at which it was created.
+func (lic *License) Compile(c *Config) (*Renderer, error) {
+	if lic == nil {
+		return nil, ErrNoLicense
+	}
+	cp := lic.Clone()
+	r := &Renderer{lic: &cp, cfg: c.normalize()}
+
+	var buf strings.Builder
+	if err := r.lic.WriteText(&buf, &r.cfg); err != nil {
+		return nil, err
+	}
+	r.text = buf.String()
+	if r.lic.PerFile != "" {
+		buf.Reset()
+		text := r.lic.PerFile
+		if err := r.lic.execute(&buf, r.lic.cleanup(text, r.cfg.TabWidth).String(), r.lic.delims(text), &r.cfg); err != nil {
+			return nil, err
+		}
+		r.notice = buf.String()
+	}
+	return r, nil
+}
+
+// WriteText writes the main license text to w, as License.WriteText.
+func (r *Renderer) WriteText(w io.Writer) error {
+	_, err := io.WriteString(w, r.text)
+	return err
+}
+
+// EditBytes returns a copy of src with the per-file license text edited into
+// it, as License.EditBytes.
+func (r *Renderer) EditBytes(src []byte, indent Indenting) ([]byte, error) {
+	if r.lic.PerFile == "" {
+		return src, nil
+	}
+	re, err := r.pattern(indent)
+	if err != nil {
+		return nil, err
+	} else if hasNotice(re, src) {
+		return nil, ErrAlreadyLicensed
+	}
+	notice := indent.fix(newBlock(r.notice).trimSpace()).append("").String()
+	return insertNotice(src, notice, &r.cfg, indent), nil
+}
+
+// Edit reads the contents of a file from r and writes them to w with the per
+// file license text edited in, as License.Edit.
+func (r *Renderer) Edit(in io.Reader, w io.Writer, indent Indenting) error {
+	return editStream(in, w, func(src []byte) ([]byte, error) {
+		return r.EditBytes(src, indent)
+	})
+}
+
+// EditFile edits the per-file license text into f, as License.EditFile.
+func (r *Renderer) EditFile(f *os.File, indent Indenting) error {
+	if r.lic.PerFile == "" {
+		return ErrNoPerFile
+	}
+	return rewriteFile(f, r.cfg.Backup, func(src []byte) ([]byte, error) {
+		return r.EditBytes(src, indent)
+	})
+}
+
+// pattern returns the notice pattern for indent, as License.noticePattern,
+// compiling it only on first use.
+func (r *Renderer) pattern(indent Indenting) (*regexp.Regexp, error) {
+	key := indent.fix(r.lic.cleanup(r.lic.PerFile, 0)).String()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if re, ok := r.patterns[key]; ok {
+		return re, nil
+	}
+	re, err := r.lic.noticePattern(indent)
+	if err != nil {
+		return nil, err
+	}
+	if r.patterns == nil {
+		r.patterns = make(map[string]*regexp.Regexp)
+	}
+	r.patterns[key] = re
+	return re, nil
+}
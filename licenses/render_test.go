@@ -0,0 +1,67 @@
+// Copyright (C) 2018 Michael J. Fromberger. All Rights Reserved.
+
+package licenses
+
+import (
+	"fmt"
+	"testing"
+)
+
+// smallFiles returns n distinct small source files, as a repository might have.
+func smallFiles(n int) [][]byte {
+	files := make([][]byte, n)
+	for i := range files {
+		files[i] = fmt.Appendf(nil, "package p%d\n\nfunc F%d() int { return %d }\n", i, i, i)
+	}
+	return files
+}
+
+func TestRendererEditBytes(t *testing.T) {
+	rnd, err := generic.Compile(testConfig)
+	if err != nil {
+		t.Fatalf("Compile: unexpected error: %v", err)
+	}
+	for _, src := range smallFiles(3) {
+		want, err := generic.EditBytes(src, testConfig, IPrefix("// "))
+		if err != nil {
+			t.Fatalf("License.EditBytes: unexpected error: %v", err)
+		}
+		got, err := rnd.EditBytes(src, IPrefix("// "))
+		if err != nil {
+			t.Fatalf("Renderer.EditBytes: unexpected error: %v", err)
+		} else if string(got) != string(want) {
+			t.Errorf("Renderer.EditBytes: got %q, want %q", got, want)
+		}
+	}
+}
+
+func BenchmarkEditBytes(b *testing.B) {
+	files := smallFiles(1000)
+	indent := IPrefix("// ")
+	b.ResetTimer()
+	for range b.N {
+		for _, src := range files {
+			if _, err := generic.EditBytes(src, testConfig, indent); err != nil {
+				b.Fatalf("EditBytes: %v", err)
+			}
+		}
+	}
+}
+
+func BenchmarkRendererEditBytes(b *testing.B) {
+	files := smallFiles(1000)
+	indent := IPrefix("// ")
+	b.ResetTimer()
+	for range b.N {
+		// Compile once for all the files, as the lice tool does for each run.
+		rnd, err := generic.Compile(testConfig)
+		if err != nil {
+			b.Fatalf("Compile: %v", err)
+		}
+		for _, src := range files {
+			if _, err := rnd.EditBytes(src, indent); err != nil {
+				b.Fatalf("EditBytes: %v", err)
+			}
+		}
+	}
+}
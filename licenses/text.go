@@ -3,8 +3,10 @@
 package licenses
 
 import (
+	"slices"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 )
 
 func newBlock(s string) *block {
@@ -34,29 +36,102 @@ func (t *block) trimSpace() *block {
 	return t
 }
 
+// collapseBlanks replaces each run of consecutive blank lines in t with a
+// single blank line.
+func (t *block) collapseBlanks() *block {
+	out := t.lines[:0]
+	for _, line := range t.lines {
+		if line == "" && len(out) != 0 && out[len(out)-1] == "" {
+			continue
+		}
+		out = append(out, line)
+	}
+	t.lines = out
+	return t
+}
+
+// untabify expands the tabs in each line of t to spaces, aligned to tab stops
+// every width columns. If width <= 0, a default of 4 is used.
 func (t *block) untabify(width int) *block {
 	if width <= 0 {
 		width = 4
 	}
-	spaces := strings.Repeat(" ", width)
 	for i, line := range t.lines {
-		t.lines[i] = strings.Replace(line, "\t", spaces, -1)
+		if !strings.Contains(line, "\t") {
+			continue
+		}
+		var buf strings.Builder
+		col := 0
+		for _, c := range line {
+			if c == '\t' {
+				n := width - col%width
+				buf.WriteString(strings.Repeat(" ", n))
+				col += n
+				continue
+			}
+			buf.WriteRune(c)
+			col++
+		}
+		t.lines[i] = buf.String()
 	}
 	return t
 }
 
+// leftJust removes from each line of t the amount of leading space common to
+// all non-blank lines. Space is measured in runes, so that an indent made of
+// multi-byte space characters counts the same as one made of ASCII spaces.
 func (t *block) leftJust() *block {
-	var min *string
+	n := -1
 	for _, line := range t.lines {
-		spc := leftSpace(line)
-		if line != "" && (min == nil || len(spc) < len(*min)) {
-			min = &spc
+		if line == "" {
+			continue
+		}
+		if w := utf8.RuneCountInString(leftSpace(line)); n < 0 || w < n {
+			n = w
 		}
 	}
-	if min != nil {
-		for i, line := range t.lines {
-			t.lines[i] = strings.TrimPrefix(line, *min)
+	if n <= 0 {
+		return t
+	}
+	for i, line := range t.lines {
+		for j := 0; j < n && line != ""; j++ {
+			r, size := utf8.DecodeRuneInString(line)
+			if !unicode.IsSpace(r) {
+				break
+			}
+			line = line[size:]
+		}
+		t.lines[i] = line
+	}
+	return t
+}
+
+// center centers each non-blank line of t within the given width, discarding
+// its existing leading and trailing space. Lines too long to fit are left
+// justified.
+func (t *block) center(width int) *block {
+	for i, line := range t.lines {
+		line = strings.TrimSpace(line)
+		pad := (width - utf8.RuneCountInString(line)) / 2
+		if line != "" && pad > 0 {
+			line = strings.Repeat(" ", pad) + line
+		}
+		t.lines[i] = line
+	}
+	return t
+}
+
+// rightJust aligns the end of each non-blank line of t to the given width,
+// discarding its existing leading and trailing space. Lines too long to fit
+// are left justified.
+func (t *block) rightJust(width int) *block {
+	for i, line := range t.lines {
+		line = strings.TrimSpace(line)
+		pad := width - utf8.RuneCountInString(line)
+		if line != "" && pad > 0 {
+			line = strings.Repeat(" ", pad) + line
 		}
+		t.lines[i] = line
 	}
 	return t
 }
@@ -71,6 +146,49 @@ func (t *block) indent(ind string) *block {
 	return t
 }
 
+func (t *block) frame(left, right string, width int) *block {
+	for i, line := range t.lines {
+		pad := width - utf8.RuneCountInString(left+line+right)
+		t.lines[i] = left + line + strings.Repeat(" ", max(pad, 1)) + right
+	}
+	return t
+}
+
+// wrap reflows the paragraphs of t so that no line is longer than width,
+// except where a single word does not fit. Paragraphs are separated by blank
+// lines; indented lines are kept as they are.
+func (t *block) wrap(width int) *block {
+	var out, para []string
+	flush := func() {
+		var line string
+		for _, w := range para {
+			if line != "" && utf8.RuneCountInString(line)+1+utf8.RuneCountInString(w) > width {
+				out = append(out, line)
+				line = ""
+			}
+			if line != "" {
+				line += " "
+			}
+			line += w
+		}
+		if line != "" {
+			out = append(out, line)
+		}
+		para = nil
+	}
+	for _, line := range t.lines {
+		if line == "" || leftSpace(line) != "" {
+			flush()
+			out = append(out, line)
+			continue
+		}
+		para = append(para, strings.Fields(line)...)
+	}
+	flush()
+	t.lines = out
+	return t
+}
+
 func (t *block) prepend(s string) *block {
 	t.lines = append([]string{s}, t.lines...)
 	return t
@@ -94,6 +212,23 @@ func leftSpace(s string) string {
 	return left
 }
 
+// Comment returns text formatted with indent, exactly as the per-file notice of
+// a license is formatted for insertion into a file, so that callers can use
+// the comment styles defined by this package for text of their own. Leading
+// and trailing blank lines and trailing whitespace are removed, tabs are
+// expanded to the default tab stops, and indentation common to all lines is
+// removed before indent is applied. If indent == nil, the text is not
+// decorated. The result ends with a newline.
+func Comment(text string, indent Indenting) string {
+	return indent.fix(cleanText(text, 0)).append("").String()
+}
+
+// cleanText normalizes the whitespace of text for formatting, expanding tabs
+// to stops every tabWidth columns (see untabify).
+func cleanText(text string, tabWidth int) *block {
+	return newBlock(text).trimSpace().untabify(tabWidth).leftJust()
+}
+
 // An Indenting is a rule for indenting or commenting license text for
 // insertion into a file. A nil Indenting leaves the input text unmodified.
 type Indenting func(*block) *block
@@ -105,12 +240,99 @@ func (in Indenting) fix(b *block) *block {
 	return in(b)
 }
 
+// probe applies in to two paragraphs of text to discover its markers. It
+// returns the lines added before and after the text, the marker added to the
+// left of the first line, and the markers added to the left and right of
+// subsequent lines, without surrounding padding. The paragraphs are separated
+// by a blank line so that an indenting that reflows the text, as IWrap does,
+// keeps them apart.
+func (in Indenting) probe() (head, tail []string, first, left, right string) {
+	const text = "x"
+	lines := in.fix(newBlock(text + "\n\n" + text)).lines
+	i := slices.IndexFunc(lines, func(line string) bool { return strings.Contains(line, text) })
+	j := len(lines) - 1
+	for j > i && !strings.Contains(lines[j], text) {
+		j--
+	}
+	if i < 0 || j == i {
+		return nil, nil, "", "", ""
+	}
+	first, _, _ = strings.Cut(lines[i], text)
+	left, post, _ := strings.Cut(lines[j], text)
+	return lines[:i], lines[j+1:], first, left, strings.TrimSpace(post)
+}
+
+// unfix reverses the effect of fix on b, removing the lines that in adds
+// before and after the text and the markers it adds to each line. Lines that
+// do not carry the markers are left as they are.
+func (in Indenting) unfix(b *block) *block {
+	if in == nil {
+		return b
+	}
+	head, tail, first, left, right := in.probe()
+	same := func(a, b string) bool { return strings.TrimSpace(a) == strings.TrimSpace(b) }
+	lines := b.lines
+	for len(head) != 0 && len(lines) != 0 && same(lines[0], head[0]) {
+		head, lines = head[1:], lines[1:]
+	}
+	for len(tail) != 0 && len(lines) != 0 && same(lines[len(lines)-1], tail[len(tail)-1]) {
+		tail, lines = tail[:len(tail)-1], lines[:len(lines)-1]
+	}
+	for i, line := range lines {
+		if right != "" {
+			if s, ok := strings.CutSuffix(strings.TrimRight(line, " \t"), right); ok {
+				line = strings.TrimRight(s, " \t")
+			}
+		}
+		marker := left
+		if i == 0 {
+			marker = first
+		}
+		if s, ok := strings.CutPrefix(line, marker); ok {
+			line = s
+		} else if same(line, marker) {
+			line = "" // a blank line, with trailing space trimmed by fix
+		}
+		lines[i] = line
+	}
+	b.lines = lines
+	return b
+}
+
+// reflows reports whether in moves words between lines, as IWrap does.
+func (in Indenting) reflows() bool {
+	var n int
+	for _, line := range in.fix(newBlock(strings.Repeat("x ", 200))).lines {
+		if strings.Contains(line, "x") {
+			n++
+		}
+	}
+	return n > 1
+}
+
+// margin reports the number of columns that in adds to a line of text.
+func (in Indenting) margin() int {
+	_, _, _, left, right := in.probe()
+	n := utf8.RuneCountInString(left)
+	if right != "" {
+		n += 1 + utf8.RuneCountInString(right)
+	}
+	return n
+}
+
 // IPrefix constructs an Indenting that prefixes each line of text with the
 // specified marker.
 func IPrefix(marker string) Indenting {
 	return func(b *block) *block { return b.indent(marker) }
 }
 
+// INone constructs an Indenting that leaves the text undecorated. Unlike a nil
+// Indenting, which has the same effect, it can be used to express an explicit
+// choice, as for License.DefaultIndent.
+func INone() Indenting {
+	return func(b *block) *block { return b }
+}
+
 // IComment constructs an Indenting that prefixes the lines of text with the
 // given comment markers.
 func IComment(first, rest, last string) Indenting {
@@ -118,3 +340,72 @@ func IComment(first, rest, last string) Indenting {
 		return b.indent(rest).prepend(first).append(last)
 	}
 }
+
+// ICommentInline constructs an Indenting like IComment, except that the first
+// line of text follows the opening marker on the same line, for example:
+//
+//	/* Copyright ...
+//	 * Licensed under ...
+//	 */
+func ICommentInline(first, rest, last string) Indenting {
+	return func(b *block) *block {
+		if len(b.lines) == 0 {
+			return b.prepend(first).append(last)
+		}
+		head := b.lines[0]
+		b.indent(rest).lines[0] = strings.TrimRight(first+head, " \t")
+		return b.append(last)
+	}
+}
+
+// ICommentCompact constructs an Indenting like ICommentInline, except that
+// the closing marker follows the last line of text on the same line, so that
+// the comment takes no lines beyond the text itself, for example:
+//
+//	/* Copyright ...
+//	   Licensed under ... */
+func ICommentCompact(first, rest, last string) Indenting {
+	inline := ICommentInline(first, rest, "")
+	return func(b *block) *block {
+		b = inline(b)
+		b.lines = b.lines[:len(b.lines)-1] // the empty closing line
+		n := len(b.lines) - 1
+		b.lines[n] = strings.TrimRight(b.lines[n]+last, " \t")
+		return b
+	}
+}
+
+// IBox constructs an Indenting that encloses the lines of text in a box drawn
+// with the given character, with top and bottom rules and the right edge of
+// the box at the specified width. Lines too long to fit extend past the edge.
+func IBox(char string, width int) Indenting {
+	rule := strings.Repeat(char, max(width/max(utf8.RuneCountInString(char), 1), 1))
+	return func(b *block) *block {
+		return b.frame(char+" ", char, width).prepend(rule).append(rule)
+	}
+}
+
+// IWrap constructs an Indenting that reflows the paragraphs of text so that
+// each line fits within the given width once inner has been applied, and then
+// applies inner. Blank lines and indented lines are preserved.
+func IWrap(inner Indenting, width int) Indenting {
+	return func(b *block) *block {
+		return inner.fix(b.wrap(width - inner.margin()))
+	}
+}
+
+// ICenter constructs an Indenting that centers each line of text within the
+// given width once inner has been applied, and then applies inner.
+func ICenter(inner Indenting, width int) Indenting {
+	return func(b *block) *block {
+		return inner.fix(b.center(width - inner.margin()))
+	}
+}
+
+// IRight constructs an Indenting that aligns the end of each line of text to
+// the given width once inner has been applied, and then applies inner.
+func IRight(inner Indenting, width int) Indenting {
+	return func(b *block) *block {
+		return inner.fix(b.rightJust(width - inner.margin()))
+	}
+}
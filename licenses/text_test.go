@@ -0,0 +1,115 @@
+// Copyright (C) 2018 Michael J. Fromberger. All Rights Reserved.
+
+package licenses
+
+import "testing"
+
+func TestAlignment(t *testing.T) {
+	const text = "Title\n\n  A longer line  \nThis line is much too long to fit"
+	tests := []struct {
+		name   string
+		indent Indenting
+		want   string
+	}{
+		{"ICenter", ICenter(nil, 20), "       Title\n\n   A longer line\nThis line is much too long to fit\n"},
+		{"IRight", IRight(nil, 20), "               Title\n\n       A longer line\nThis line is much too long to fit\n"},
+		{"IRight/IPrefix", IRight(IPrefix("# "), 20), "#              Title\n#\n#      A longer line\n# This line is much too long to fit\n"},
+		{"IRight/IBox", IRight(IBox("#", 20), 20),
+			"####################\n" +
+				"#            Title #\n" +
+				"#                  #\n" +
+				"#    A longer line #\n" +
+				"# This line is much too long to fit #\n" +
+				"####################\n"},
+	}
+	for _, test := range tests {
+		if got := Comment(text, test.indent); got != test.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", test.name, got, test.want)
+		}
+	}
+}
+
+func TestLeftJustRunes(t *testing.T) {
+	const nbsp = "\u00a0"
+	tests := []struct {
+		text, want string
+	}{
+		// An indent of non-breaking spaces counts one column per rune.
+		{"  one\n" + nbsp + nbsp + "two\n", "one\ntwo\n"},
+		{nbsp + nbsp + "one\n" + nbsp + nbsp + nbsp + "two\n", "one\n" + nbsp + "two\n"},
+		{"   one\n" + nbsp + nbsp + "two\n", " one\ntwo\n"},
+		{nbsp + "one\n\n" + nbsp + "two\n", "one\n\ntwo\n"},
+	}
+	for _, test := range tests {
+		if got := Comment(test.text, nil); got != test.want {
+			t.Errorf("Comment(%q): got %q, want %q", test.text, got, test.want)
+		}
+	}
+}
+
+func TestUntabify(t *testing.T) {
+	tests := []struct {
+		text  string
+		width int
+		want  string
+	}{
+		{"a\tb", 4, "a   b"},
+		{"ab\tc", 4, "ab  c"},
+		{"abcd\te", 4, "abcd    e"},
+		{"\ta\tb", 4, "    a   b"},
+		{"name:\tvalue\nkey:\tvalue", 8, "name:   value\nkey:    value"},
+		{"a\tb", 0, "a   b"}, // the default width
+		{"ü\tb", 4, "ü   b"}, // columns are runes, not bytes
+	}
+	for _, test := range tests {
+		if got := newBlock(test.text).untabify(test.width).String(); got != test.want {
+			t.Errorf("untabify(%q, %d): got %q, want %q", test.text, test.width, got, test.want)
+		}
+	}
+}
+
+func TestCommentLayouts(t *testing.T) {
+	sstar := IComment("/*", " * ", " */")
+	istar := ICommentInline("/* ", " * ", " */")
+	tests := []struct {
+		text         string
+		sstar, istar string
+	}{
+		{"Copyright\nLicensed",
+			"/*\n * Copyright\n * Licensed\n */\n",
+			"/* Copyright\n * Licensed\n */\n"},
+		{"Copyright\n\nLicensed",
+			"/*\n * Copyright\n *\n * Licensed\n */\n",
+			"/* Copyright\n *\n * Licensed\n */\n"},
+		{"Copyright",
+			"/*\n * Copyright\n */\n",
+			"/* Copyright\n */\n"},
+	}
+	for _, test := range tests {
+		if got := Comment(test.text, sstar); got != test.sstar {
+			t.Errorf("sstar: Comment(%q): got %q, want %q", test.text, got, test.sstar)
+		}
+		if got := Comment(test.text, istar); got != test.istar {
+			t.Errorf("istar: Comment(%q): got %q, want %q", test.text, got, test.istar)
+		}
+	}
+}
+
+func TestUnfix(t *testing.T) {
+	indents := map[string]Indenting{
+		"prefix":  IPrefix("// "),
+		"comment": IComment("/*", " * ", " */"),
+		"inline":  ICommentInline("/* ", " * ", " */"),
+		"compact": ICommentCompact("/* ", "   ", " */"),
+		"box":     IBox("#", 30),
+		"wrap":    IWrap(IComment("/*", " * ", " */"), 30),
+	}
+	// Each paragraph fits on one line, so that wrapping does not move words.
+	const text = "Copyright (C) 2024 Alice.\n\nLicensed under the terms."
+	for name, in := range indents {
+		fixed := in.fix(newBlock(text))
+		if got := in.unfix(newBlock(fixed.String())).String(); got != text {
+			t.Errorf("%s: unfix(%q): got %q, want %q", name, fixed, got, text)
+		}
+	}
+}
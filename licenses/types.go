@@ -8,11 +8,15 @@ package licenses
 // https://www.gnu.org/licenses/license-list.en.html
 
 import (
+	"bytes"
+	"cmp"
 	"errors"
 	"fmt"
 	"io"
 	"os"
-	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
 	"text/template"
 	"time"
 )
@@ -20,7 +24,29 @@ import (
 // PerFileNotice is a generic per-file license statement that can be added to
 // any license that does not have more specific language to recommend.
 const PerFileNotice = `
-Copyright (C) {{date "2006"}} {{.Author}}. All Rights Reserved.
+{{copyright}} {{date "2006"}} {{authors}}. All Rights Reserved.
+`
+
+// SPDXNotice is a compact per-file license statement that identifies the
+// license only by its SPDX identifier, following the REUSE convention.
+// See https://reuse.software/.
+const SPDXNotice = `
+SPDX-License-Identifier: {{spdx}}
+`
+
+// SidecarNotice is the content of a REUSE ".license" file, which carries the
+// copyright and license information for a file that cannot contain comments.
+// See https://reuse.software/spec/.
+const SidecarNotice = `
+SPDX-FileCopyrightText: {{date "2006"}} {{authors}}
+
+SPDX-License-Identifier: {{spdx}}
+`
+
+// PointerNotice is a one-line per-file statement that names the license and
+// refers the reader to the license file, rather than quoting a notice.
+const PointerNotice = `
+Licensed under the {{.License.Name}} (see LICENSE).
 `
 
 // A License describes a software license.
@@ -28,6 +54,10 @@ Copyright (C) {{date "2006"}} {{.Author}}. All Rights Reserved.
 // A package that implements a license should call license.Register during init
 // with a value of this type, suitably populated with values corresponding to
 // the details of that license.
+//
+// The templates of a license are executed with the fields of a Config and of
+// the License itself as their context, so that for example a notice may refer
+// to {{.Author}}, {{.Name}}, or {{.URL}}.
 type License struct {
 	// A human-readable name of the license.
 	// For example: "Apache License, Version 2.0".
@@ -38,113 +68,547 @@ type License struct {
 	// with no spaces.
 	Slug string
 
+	// Alternative names that may be used to look up the license (optional).
+	// Like the slug, each alias must be unique across all registered licenses.
+	Aliases []string
+
 	// A URL to a description of the license (optional).
 	URL string
 
+	// The SPDX license identifier for the license (optional).
+	// For example: "Apache-2.0". See https://spdx.org/licenses/.
+	SPDX string
+
+	// The broad category of terms the license grants (optional).
+	Category Category
+
 	// The text of the license (template, required).
 	Text string
 
 	// Additional license text that must be inserted into each file covered by
 	// the license (template, optional).
 	PerFile string
+
+	// The text of a NOTICE file to accompany the license, for licenses such as
+	// Apache 2.0 that expect one (template, optional).
+	Notice string
+
+	// The indenting to use for the per-file notice when the user has not
+	// chosen one, in place of a comment style guessed from the type of the
+	// file (optional). This is useful for licenses such as Creative Commons
+	// whose notices are attributions in plain text rather than code comments.
+	// See INone.
+	DefaultIndent Indenting
+
+	// Alternative left and right delimiters for the templates (optional).
+	// If unset, the default "{{" and "}}" are used. This is useful for texts
+	// that contain literal double braces. The notices defined by this package,
+	// such as SPDXNotice, always use the default delimiters.
+	Delims [2]string
+
+	// If true, runs of consecutive blank lines in the templates are collapsed
+	// to a single blank line before rendering. By default blank lines are kept
+	// as written.
+	CollapseBlanks bool
+
+	// Parsed templates for this license, shared among copies of a registered
+	// value. If nil, templates are parsed on each use.
+	cache *templateCache
 }
 
+// Clone returns a copy of lic that shares no mutable state with it, so that a
+// caller may modify the copy, for example to customize the text of a license
+// returned by Lookup, without affecting lic or the registry. If lic has a
+// template cache, the copy has a new, empty one of its own.
+func (lic License) Clone() License {
+	lic.Aliases = slices.Clone(lic.Aliases)
+	if lic.cache != nil {
+		lic.cache = new(templateCache)
+	}
+	return lic
+}
+
+// A templateCache holds parsed templates keyed by their source text.
+type templateCache struct {
+	mu sync.Mutex
+	m  map[cacheKey]*template.Template
+}
+
+type cacheKey struct {
+	delims [2]string
+	text   string
+}
+
+// A Category classifies licenses by the broad character of their terms.
+type Category string
+
+// Categories of licenses.
+const (
+	Permissive     Category = "permissive"      // e.g., MIT, BSD, Apache
+	WeakCopyleft   Category = "weak-copyleft"   // e.g., LGPL, MPL
+	StrongCopyleft Category = "strong-copyleft" // e.g., GPL
+	PublicDomain   Category = "public-domain"   // e.g., CC0
+)
+
 // Config carries parameters to be expanded by text templates for a license.
 type Config struct {
 	// The name of the author, to whom copyright is attributed.
 	Author string
 
+	// The names of multiple authors for a joint work (optional). If set, this
+	// takes precedence over Author. The template can render the names using
+	// the "authors" function provided in the function map.
+	Authors []string
+
 	// The name of the project to which the license is attached, if different
 	// from the author. Example: "FreeBSD".
 	Project string
 
+	// The name of the program covered by the license, for licenses such as
+	// the GNU GPL whose per-file notices name it (optional). If empty, such
+	// notices refer to "this program" instead.
+	Program string
+
 	// The current time. The template can render this field using the "time" and
-	// "date" functions provided in the function map.
+	// "date" functions provided in the function map. If zero, the time at which
+	// the template is rendered is used.
+	//
+	// The function map also provides "spdx", which renders the SPDX identifier
+	// of the license being rendered, or "" if it does not have one, and
+	// "copyright", which renders Notation.
 	Time time.Time
+
+	// If non-empty, EditFile saves the original contents of the file it edits
+	// to a file whose name is the original name with this suffix appended,
+	// for example "~".
+	Backup string
+
+	// The number of columns between tab stops, used to expand tabs in the
+	// license text to spaces. If zero, a default of 4 is used.
+	TabWidth int
+
+	// If true, EditBytes inserts the per-file notice after a comment block at
+	// the top of the file, such as a description of its contents, rather than
+	// above it.
+	AfterComment bool
+
+	// If true, licenses that support it mark the work as incompatible with
+	// secondary licenses, for example with Exhibit B of the Mozilla Public
+	// License 2.0.
+	IncompatibleSecondary bool
+
+	// If true, EditBytes appends the per-file notice at the end of the file,
+	// rather than inserting it at the top. This takes precedence over
+	// AfterComment.
+	AtEnd bool
+
+	// The phrase that introduces a copyright statement, rendered by the
+	// "copyright" function in the function map, for example "Copyright ©" or
+	// "(c)". If empty, the default is "Copyright (C)". The generic per-file
+	// notice uses this phrase, but the texts of most licenses do not. The
+	// phrase should include "©" or "(c)", as HasNotice requires one of them
+	// to recognize the notice.
+	Notation string
+
+	// Additional values for custom templates (optional). The template can
+	// render these as {{.Vars.name}}. The built-in licenses do not use them.
+	Vars map[string]string
 }
 
-// newTemplate parses a text template initialized with the helpers provided by
-// c, and returns a function that will execute the template into an io.Writer
-// using c as its context.
-func (c Config) newTemplate(text string) (func(io.Writer) error, error) {
-	t, err := template.New("text").Funcs(template.FuncMap{
+// templateData is the context in which the templates for a license are
+// executed. The fields of the Config and of the license itself are available
+// directly, for example {{.Author}} or {{.SPDX}}. The license is also
+// available as .License, for example {{.License.Name}}.
+type templateData struct {
+	Config
+	*License
+}
+
+// normalize returns a copy of c with defaults filled in for unset fields.
+func (c Config) normalize() Config {
+	if c.Time.IsZero() {
+		c.Time = time.Now()
+	}
+	return c
+}
+
+// funcs returns a function map binding the template helpers to lic and c.
+func (lic *License) funcs(c Config) template.FuncMap {
+	return template.FuncMap{
+		"authors": c.authors,
+		"copyright": func() string {
+			return cmp.Or(c.Notation, "Copyright (C)")
+		},
 		"date": c.Time.Format,
+		"spdx": func() string { return lic.SPDX },
 		"time": c.Time.Format,
-	}).Parse(text)
+	}
+}
+
+// authors renders the author names from c as a single string. Multiple names
+// are separated by commas, with "and" before the last.
+func (c Config) authors() string {
+	switch n := len(c.Authors); n {
+	case 0:
+		return c.Author
+	case 1:
+		return c.Authors[0]
+	case 2:
+		return c.Authors[0] + " and " + c.Authors[1]
+	default:
+		return strings.Join(c.Authors[:n-1], ", ") + ", and " + c.Authors[n-1]
+	}
+}
+
+// delims returns the template delimiters for text, one of the templates of
+// lic before cleanup. The notices defined by this package, such as SPDXNotice,
+// use the default delimiters whatever lic.Delims are, so that they serve for
+// any license.
+func (lic *License) delims(text string) [2]string {
+	switch text {
+	case PerFileNotice, SPDXNotice, SidecarNotice, PointerNotice:
+		return [2]string{}
+	}
+	return lic.Delims
+}
+
+// parse returns a parsed template for text, with the given delimiters. If lic
+// has a cache, the result is stored there and reused by later calls with the
+// same text. The helper functions in the resulting template are placeholders;
+// use execute to bind them to a specific Config.
+func (lic *License) parse(text string, delims [2]string) (*template.Template, error) {
+	key := cacheKey{delims: delims, text: text}
+	if tc := lic.cache; tc != nil {
+		tc.mu.Lock()
+		defer tc.mu.Unlock()
+		if t, ok := tc.m[key]; ok {
+			return t, nil
+		}
+	}
+	t, err := template.New("text").
+		Delims(delims[0], delims[1]).
+		Funcs(lic.funcs(Config{})).
+		Parse(text)
+	if err != nil {
+		return nil, &TemplateError{Stage: "parsing", Err: err}
+	}
+	if tc := lic.cache; tc != nil {
+		if tc.m == nil {
+			tc.m = make(map[cacheKey]*template.Template)
+		}
+		tc.m[key] = t
+	}
+	return t, nil
+}
+
+// execute renders the template for text, with the given delimiters, into w
+// using c and lic as its context, as described by templateData.
+func (lic *License) execute(w io.Writer, text string, delims [2]string, c *Config) error {
+	t, err := lic.parse(text, delims)
+	if err != nil {
+		return err
+	}
+	// Clone the template so that binding the helpers to c does not affect
+	// concurrent or later uses of the cached copy.
+	t, err = t.Clone()
 	if err != nil {
-		return nil, fmt.Errorf("parsing template: %v", err)
+		return err
+	}
+	cfg := c.normalize()
+	err = t.Funcs(lic.funcs(cfg)).Execute(w, templateData{Config: cfg, License: lic})
+	var ee template.ExecError
+	if errors.As(err, &ee) {
+		return &TemplateError{Stage: "executing", Err: err}
+	}
+	return err // nil, or an error writing to w
+}
+
+// A TemplateError reports a failure to parse or to execute one of the
+// templates of a license, as distinct from an error reading or writing data.
+type TemplateError struct {
+	Stage string // "parsing" or "executing"
+	Err   error  // the error reported by the text/template package
+}
+
+func (e *TemplateError) Error() string { return e.Stage + " template: " + e.Err.Error() }
+
+// Unwrap returns the underlying error from the text/template package.
+func (e *TemplateError) Unwrap() error { return e.Err }
+
+// render executes the template text with c and applies indent to the result.
+// The template is rendered before indenting so that indentings which depend
+// on the width of each line see the text as it will appear.
+func (lic *License) render(text string, c *Config, indent Indenting) (*block, error) {
+	var buf strings.Builder
+	if err := lic.execute(&buf, lic.cleanup(text, c.TabWidth).String(), lic.delims(text), c); err != nil {
+		return nil, err
 	}
-	return func(w io.Writer) error {
-		return t.Execute(w, c)
-	}, nil
+	return indent.fix(newBlock(buf.String()).trimSpace()), nil
 }
 
-func cleanup(text string) *block {
-	return newBlock(text).trimSpace().untabify(0).leftJust()
+// cleanup normalizes the whitespace of the template text for rendering.
+func (lic *License) cleanup(text string, tabWidth int) *block {
+	b := cleanText(text, tabWidth)
+	if lic.CollapseBlanks {
+		b.collapseBlanks()
+	}
+	return b
 }
 
-// WriteText renders the main license text to w.
+// WriteText renders the main license text to w. The output ends with a
+// newline, as is suitable for a license file. Use WriteBody to omit it.
 func (lic *License) WriteText(w io.Writer, c *Config) error {
 	if lic == nil {
-		return errors.New("no license found")
+		return ErrNoLicense
 	}
-	clean := cleanup(lic.Text).append("") // ensure file ends with a newline
-	write, err := c.newTemplate(clean.String())
-	if err != nil {
-		return err
+	clean := lic.cleanup(lic.Text, c.TabWidth).append("") // ensure file ends with a newline
+	return lic.execute(w, clean.String(), lic.delims(lic.Text), c)
+}
+
+// WriteBody renders the main license text to w like WriteText, but without a
+// newline after the last line of text. This is useful when the text is to be
+// embedded in a larger document, where the caller controls the spacing.
+func (lic *License) WriteBody(w io.Writer, c *Config) error {
+	if lic == nil {
+		return ErrNoLicense
 	}
-	return write(w)
+	return lic.execute(w, lic.cleanup(lic.Text, c.TabWidth).String(), lic.delims(lic.Text), c)
 }
 
-// EditFile edits the per file license text into f. If the license has no
-// per-file text, this does nothing without error. The indent controls how the
-// text is indented or commented; if indent == nil it is inserted verbatim.
-func (lic *License) EditFile(f *os.File, c *Config, indent Indenting) error {
-	if lic == nil || lic.PerFile == "" {
+// WriteNotice renders the notice text to w. If the license has no notice
+// text, this does nothing without error.
+func (lic *License) WriteNotice(w io.Writer, c *Config) error {
+	if lic == nil {
+		return ErrNoLicense
+	} else if lic.Notice == "" {
 		return nil
 	}
+	clean := lic.cleanup(lic.Notice, c.TabWidth).append("")
+	return lic.execute(w, clean.String(), lic.delims(lic.Notice), c)
+}
 
-	// Find where the file is located so we can create a tempfile in the same
-	// directory.
-	abs, err := filepath.Abs(f.Name())
+// WriteSPDXHeader renders a one-line SPDX license identifier to w, using
+// indent to control how it is indented or commented. It is an error if the
+// license does not have an SPDX identifier.
+func (lic *License) WriteSPDXHeader(w io.Writer, c *Config, indent Indenting) error {
+	if lic == nil {
+		return ErrNoLicense
+	} else if lic.SPDX == "" {
+		return fmt.Errorf("license %q has no SPDX identifier", lic.Slug)
+	}
+	clean, err := lic.render(SPDXNotice, c, indent)
 	if err != nil {
 		return err
 	}
+	_, err = io.WriteString(w, clean.append("").String())
+	return err
+}
 
-	// Seek to the beginning of the old file, so we can copy it fully.
-	if _, err := f.Seek(0, io.SeekStart); err != nil {
+// WritePerFile renders the per-file notice to w as EditBytes would insert it,
+// using indent to control how it is indented or commented. If the license has
+// no per-file notice, this does nothing without error.
+func (lic *License) WritePerFile(w io.Writer, c *Config, indent Indenting) error {
+	if lic == nil {
+		return ErrNoLicense
+	} else if lic.PerFile == "" {
+		return nil
+	}
+	clean, err := lic.render(lic.PerFile, c, indent)
+	if err != nil {
 		return err
 	}
+	_, err = io.WriteString(w, clean.append("").String())
+	return err
+}
+
+// WriteSidecar renders the contents of a REUSE ".license" file to w, giving
+// the copyright and SPDX license identifier for a file that cannot carry them
+// in a comment, such as an image. It is an error if the license does not have
+// an SPDX identifier.
+func (lic *License) WriteSidecar(w io.Writer, c *Config) error {
+	if lic == nil {
+		return ErrNoLicense
+	} else if lic.SPDX == "" {
+		return fmt.Errorf("license %q has no SPDX identifier", lic.Slug)
+	}
+	clean := lic.cleanup(SidecarNotice, c.TabWidth).append("")
+	return lic.execute(w, clean.String(), lic.delims(SidecarNotice), c)
+}
+
+// Errors reported by the methods of a License.
+var (
+	// ErrNoLicense is reported when a method is called on a nil *License, as
+	// for example when the result of Lookup is used without checking.
+	ErrNoLicense = errors.New("no license found")
+
+	// ErrNoPerFile is reported by EditFile when the license has no per-file
+	// notice to insert.
+	ErrNoPerFile = errors.New("license has no per-file notice")
+
+	// ErrAlreadyLicensed is reported by EditFile when the file already has
+	// the per-file notice for the license.
+	ErrAlreadyLicensed = errors.New("file already has a license notice")
+)
+
+// EditBytes returns a copy of src with the per file license text edited into
+// it. If the license has no per-file text, it returns src unmodified. The
+// indent controls how the text is indented or commented; if indent == nil it
+// is inserted verbatim.
+//
+// If src already has the per-file notice near its beginning or end, as
+// reported by HasNotice, EditBytes reports ErrAlreadyLicensed.
+//
+// The notice is inserted at the head of the file, except that any preamble
+// that must remain at the top, such as an interpreter line ("#!...") for a
+// script or build constraints in a Go file, is kept in place above it. If
+// c.AfterComment is true, a comment block at the top of the file is likewise
+// kept above the notice. Exactly one blank line separates the notice from the
+// original content below it. If c.AtEnd is true, the notice is instead
+// appended at the end of the file, separated by one blank line from the
+// original content above it.
+//
+// The result ends with exactly one newline, even if src did not end with one
+// or ended with blank lines.
+//
+// If most lines of src end in CRLF, all the lines of the result will. A UTF-8
+// byte order mark at the beginning of src is preserved.
+func (lic *License) EditBytes(src []byte, c *Config, indent Indenting) ([]byte, error) {
+	if lic == nil || lic.PerFile == "" {
+		return src, nil
+	}
 
-	// Generate the per-file license text at the head of the file.  Ensure there
-	// is a blank separating the license text from anything else below it.
-	clean := indent.fix(cleanup(lic.PerFile)).append("\n")
-	write, err := c.newTemplate(clean.String())
+	// Leave the file alone if it already has a notice.
+	re, err := lic.noticePattern(indent)
 	if err != nil {
-		return err
+		return nil, err
+	} else if hasNotice(re, src) {
+		return nil, ErrAlreadyLicensed
 	}
 
-	// Create a tempfile to receive the annotated file.
-	tmp, err := os.CreateTemp(filepath.Dir(abs), filepath.Base(abs)+"~*")
+	clean, err := lic.render(lic.PerFile, c, indent)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	return insertNotice(src, clean.append("").String(), c, indent), nil
+}
+
+// insertNotice returns a copy of src with the rendered notice inserted as
+// described by EditBytes. The indent is used to recognize a comment block at
+// the top of src when c.AfterComment is true.
+func insertNotice(src []byte, notice string, c *Config, indent Indenting) []byte {
+	if c.AtEnd {
+		return appendNotice(src, notice)
 	}
-	defer os.Remove(tmp.Name())
 
-	// Write the annotation to tmp, then copy the original file after it.  Sync
-	// to ensure the write is committed, then close and replace the original.
-	err = write(tmp)
-	if err == nil {
-		_, err = io.Copy(tmp, f)
-		if err == nil {
-			err = tmp.Sync()
+	// Generate the per-file license text at the head of the file, after any
+	// preamble, and follow it with the rest of the original file.  Ensure there
+	// is exactly one blank line separating the license text from anything else
+	// below it.
+	// A byte-order mark, if present, must remain at the very beginning.
+	bom, body := splitBOM(src)
+	head, rest := splitPreamble(body)
+	var buf bytes.Buffer
+	buf.Write(bom)
+	buf.Write(head)
+	if len(head) != 0 && !bytes.HasSuffix(head, []byte("\n")) {
+		buf.WriteByte('\n')
+	}
+	// If requested, keep a comment block at the top of the file above the
+	// notice, separated from it by a blank line.
+	var n int
+	if c.AfterComment {
+		n = skipComment(rest, indent)
+	}
+	if n > 0 {
+		buf.Write(rest[:n])
+		if !bytes.HasSuffix(rest[:n], []byte("\n")) {
+			buf.WriteByte('\n')
+		}
+		buf.WriteByte('\n')
+		rest = rest[skipBlankLines(rest, n):]
+	}
+	buf.WriteString(notice)
+	rest = rest[:trimBlankLines(rest)]
+	if rest = rest[skipBlankLines(rest, 0):]; len(rest) != 0 {
+		buf.WriteByte('\n')
+		buf.Write(rest)
+		if !bytes.HasSuffix(rest, []byte("\n")) {
+			buf.WriteByte('\n')
 		}
 	}
-	cerr := tmp.Close()
+
+	// Match the line endings of the original file.
+	if usesCRLF(src) {
+		return toCRLF(buf.Bytes())
+	}
+	return buf.Bytes()
+}
+
+// appendNotice returns a copy of src with notice added at its end, separated
+// from the original content by exactly one blank line.
+func appendNotice(src []byte, notice string) []byte {
+	var buf bytes.Buffer
+	if body := src[:trimBlankLines(src)]; len(bytes.TrimPrefix(body, utf8BOM)) != 0 {
+		buf.Write(body)
+		if !bytes.HasSuffix(body, []byte("\n")) {
+			buf.WriteByte('\n')
+		}
+		buf.WriteByte('\n')
+	} else {
+		buf.Write(body) // only a byte-order mark, if anything
+	}
+	buf.WriteString(notice)
+	if usesCRLF(src) {
+		return toCRLF(buf.Bytes())
+	}
+	return buf.Bytes()
+}
+
+// Edit reads the contents of a file from r and writes them to w with the per
+// file license text edited in, as described by EditBytes. If the license has
+// no per-file text, or if the input already has the per-file notice, the input
+// is copied to w unmodified; in the latter case Edit reports
+// ErrAlreadyLicensed.
+func (lic *License) Edit(r io.Reader, w io.Writer, c *Config, indent Indenting) error {
+	return editStream(r, w, func(src []byte) ([]byte, error) {
+		return lic.EditBytes(src, c, indent)
+	})
+}
+
+// editStream copies r to w with the changes made by edit, as described by
+// License.Edit.
+func editStream(r io.Reader, w io.Writer, edit func([]byte) ([]byte, error)) error {
+	src, err := io.ReadAll(r)
 	if err != nil {
 		return err
-	} else if cerr != nil {
-		return cerr
 	}
-	return os.Rename(tmp.Name(), f.Name())
+	out, err := edit(src)
+	if errors.Is(err, ErrAlreadyLicensed) {
+		if _, werr := w.Write(src); werr != nil {
+			return werr
+		}
+		return err
+	} else if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
+}
+
+// EditFile edits the per file license text into f, replacing its contents as
+// described by EditBytes. If the license has no per-file text, EditFile leaves
+// f unmodified and reports ErrNoPerFile. The permissions of f are preserved.
+// If f already has the per-file notice, EditFile leaves it unmodified and
+// reports ErrAlreadyLicensed. If c.Backup is set, the original contents of f
+// are saved to a backup file once the edited contents have been staged.
+func (lic *License) EditFile(f *os.File, c *Config, indent Indenting) error {
+	if lic == nil {
+		return ErrNoLicense
+	} else if lic.PerFile == "" {
+		return ErrNoPerFile
+	}
+
+	return rewriteFile(f, c.Backup, func(src []byte) ([]byte, error) {
+		return lic.EditBytes(src, c, indent)
+	})
 }
@@ -0,0 +1,180 @@
+// Copyright (C) 2018 Michael J. Fromberger. All Rights Reserved.
+
+package licenses
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// editFile edits the generic notice into the file at path with EditFile.
+func editFile(t *testing.T, path string, indent Indenting) error {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Opening file: %v", err)
+	}
+	defer f.Close()
+	return generic.EditFile(f, testConfig, indent)
+}
+
+func TestEditFileTwice(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.go")
+	if err := os.WriteFile(path, []byte("package a\n"), 0644); err != nil {
+		t.Fatalf("Writing file: %v", err)
+	}
+	if err := editFile(t, path, IPrefix("// ")); err != nil {
+		t.Fatalf("First edit: unexpected error: %v", err)
+	}
+	if err := editFile(t, path, IPrefix("// ")); !errors.Is(err, ErrAlreadyLicensed) {
+		t.Errorf("Second edit: got error %v, want %v", err, ErrAlreadyLicensed)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Reading file: %v", err)
+	}
+	const want = "// Copyright (C) 2024 Alice. All Rights Reserved.\n\npackage a\n"
+	if got := string(data); got != want {
+		t.Errorf("After two edits: got %q, want %q", got, want)
+	}
+	if n := strings.Count(string(data), "Copyright"); n != 1 {
+		t.Errorf("After two edits: got %d notices, want 1", n)
+	}
+}
+
+// editTest is a test case for EditBytes with the generic notice.
+type editTest struct {
+	name string
+	src  string
+	want string
+}
+
+// checkEdits edits the generic notice into the source of each test with the
+// settings in cfg and the given indent, and checks that the result is as
+// expected.
+func checkEdits(t *testing.T, cfg *Config, indent Indenting, tests []editTest) {
+	t.Helper()
+	for _, test := range tests {
+		got, err := generic.EditBytes([]byte(test.src), cfg, indent)
+		if err != nil {
+			t.Errorf("%s: EditBytes: unexpected error: %v", test.name, err)
+		} else if string(got) != test.want {
+			t.Errorf("%s: EditBytes: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+// notice is the text of the generic notice as rendered with testConfig, and
+// slashNotice is the same notice commented with IPrefix("// ").
+const (
+	notice      = "Copyright (C) 2024 Alice. All Rights Reserved."
+	slashNotice = "// " + notice + "\n"
+)
+
+func TestEditSpacing(t *testing.T) {
+	checkEdits(t, testConfig, IPrefix("// "), []editTest{
+		{"code", "package a\n", slashNotice + "\npackage a\n"},
+		{"blank", "\npackage a\n", slashNotice + "\npackage a\n"},
+		{"blanks", "\n\n  \n\npackage a\n", slashNotice + "\npackage a\n"},
+		{"empty", "", slashNotice},
+		{"only blanks", "\n\n", slashNotice},
+	})
+
+	cfg := *testConfig
+	cfg.AfterComment = true
+	checkEdits(t, &cfg, IPrefix("// "), []editTest{
+		{"comment", "// Package a.\npackage a\n",
+			"// Package a.\n\n" + slashNotice + "\npackage a\n"},
+		{"comment+blanks", "// Package a.\n\n\npackage a\n",
+			"// Package a.\n\n" + slashNotice + "\npackage a\n"},
+	})
+}
+
+func TestEditAtEnd(t *testing.T) {
+	cfg := *testConfig
+	cfg.AtEnd = true
+	checkEdits(t, &cfg, IPrefix("// "), []editTest{
+		{"code", "SELECT 1;\nSELECT 2;\n", "SELECT 1;\nSELECT 2;\n\n" + slashNotice},
+		{"trailing blanks", "SELECT 1;\n\n\n", "SELECT 1;\n\n" + slashNotice},
+		{"leading blanks", "\n\nSELECT 1;\n", "\n\nSELECT 1;\n\n" + slashNotice},
+		{"empty", "", slashNotice},
+	})
+
+	// An appended notice is recognized, so a second edit leaves it alone.
+	src := []byte("package a\n\n" + slashNotice)
+	if _, err := generic.EditBytes(src, &cfg, IPrefix("// ")); !errors.Is(err, ErrAlreadyLicensed) {
+		t.Errorf("EditBytes: got error %v, want %v", err, ErrAlreadyLicensed)
+	}
+}
+
+func TestEditFinalNewline(t *testing.T) {
+	checkEdits(t, testConfig, IPrefix("// "), []editTest{
+		{"head", "package a", slashNotice + "\npackage a\n"},
+		{"head/lines", "package a\n\nvar x = 1", slashNotice + "\npackage a\n\nvar x = 1\n"},
+		{"head/blanks", "package a\n\n\n", slashNotice + "\npackage a\n"},
+		{"head/CRLF", "package a\r\nvar x = 1", strings.ReplaceAll(slashNotice+"\npackage a\nvar x = 1\n", "\n", "\r\n")},
+	})
+
+	cfg := *testConfig
+	cfg.AtEnd = true
+	checkEdits(t, &cfg, IPrefix("// "), []editTest{
+		{"end", "package a", "package a\n\n" + slashNotice},
+		{"end/spaces", "package a\n  ", "package a\n\n" + slashNotice},
+	})
+}
+
+func TestBuiltinNoticeDelims(t *testing.T) {
+	// The notices of this package use the default delimiters, even for a
+	// license that sets its own for its templates.
+	lic := &License{
+		Name:   "Braces License",
+		Slug:   "braces",
+		SPDX:   "Braces",
+		Text:   "Text of the [[.Name]] for {{ and }}.",
+		Delims: [2]string{"[[", "]]"},
+	}
+	var buf strings.Builder
+	if err := lic.WriteText(&buf, testConfig); err != nil {
+		t.Fatalf("WriteText: unexpected error: %v", err)
+	} else if got, want := buf.String(), "Text of the Braces License for {{ and }}.\n"; got != want {
+		t.Errorf("WriteText: got %q, want %q", got, want)
+	}
+
+	buf.Reset()
+	if err := lic.WriteSPDXHeader(&buf, testConfig, IPrefix("// ")); err != nil {
+		t.Fatalf("WriteSPDXHeader: unexpected error: %v", err)
+	} else if got, want := buf.String(), "// SPDX-License-Identifier: Braces\n"; got != want {
+		t.Errorf("WriteSPDXHeader: got %q, want %q", got, want)
+	}
+
+	buf.Reset()
+	if err := lic.WriteSidecar(&buf, testConfig); err != nil {
+		t.Fatalf("WriteSidecar: unexpected error: %v", err)
+	} else if got, want := buf.String(), "SPDX-FileCopyrightText: 2024 Alice\n\nSPDX-License-Identifier: Braces\n"; got != want {
+		t.Errorf("WriteSidecar: got %q, want %q", got, want)
+	}
+
+	tests := []struct {
+		perFile, want string
+	}{
+		{SPDXNotice, "// SPDX-License-Identifier: Braces\n"},
+		{PerFileNotice, "// Copyright (C) 2024 Alice. All Rights Reserved.\n"},
+		{PointerNotice, "// Licensed under the Braces License (see LICENSE).\n"},
+	}
+	const src = "package a\n"
+	for _, test := range tests {
+		lic.PerFile = test.perFile
+		got, err := lic.EditBytes([]byte(src), testConfig, IPrefix("// "))
+		if err != nil {
+			t.Fatalf("EditBytes(%q): unexpected error: %v", test.want, err)
+		} else if want := test.want + "\n" + src; string(got) != want {
+			t.Errorf("EditBytes: got %q, want %q", got, want)
+		}
+		if ok, err := lic.HasNotice(strings.NewReader(string(got)), IPrefix("// ")); err != nil || !ok {
+			t.Errorf("HasNotice(%q): got (%v, %v), want true", got, ok, err)
+		}
+	}
+}
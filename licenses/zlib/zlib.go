@@ -0,0 +1,49 @@
+// Copyright (C) 2018 Michael J. Fromberger. All Rights Reserved.
+
+// Package zlib describes the zlib software license.
+package zlib
+
+import "github.com/creachadair/lice/licenses"
+
+func init() {
+	licenses.Register(licenses.License{
+		Name:     "zlib License",
+		Slug:     "zlib",
+		URL:      "https://zlib.net/zlib_license.html",
+		SPDX:     "Zlib",
+		Category: licenses.Permissive,
+		Text:     text,
+		PerFile:  perFile,
+	})
+}
+
+// The placeholders in the original text, "<year>" and "<copyright holders>",
+// are filled in by the template. The text contains no other braces that could
+// be mistaken for template actions.
+const text = `
+Copyright (c) {{date "2006"}} {{authors}}
+
+This software is provided 'as-is', without any express or implied
+warranty.  In no event will the authors be held liable for any damages
+arising from the use of this software.
+
+Permission is granted to anyone to use this software for any purpose,
+including commercial applications, and to alter it and redistribute it
+freely, subject to the following restrictions:
+
+1. The origin of this software must not be misrepresented; you must not
+   claim that you wrote the original software. If you use this software
+   in a product, an acknowledgment in the product documentation would be
+   appreciated but is not required.
+2. Altered source versions must be plainly marked as such, and must not be
+   misrepresented as being the original software.
+3. This notice may not be removed or altered from any source distribution.
+`
+
+const perFile = `
+Copyright (c) {{date "2006"}} {{authors}}
+
+This software is provided 'as-is', without any express or implied
+warranty. It is distributed under the terms of the zlib license; see the
+accompanying license file for the conditions of use and redistribution.
+`
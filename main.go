@@ -5,12 +5,26 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"io/fs"
 	"log"
+	"maps"
 	"os"
 	"os/user"
 	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"text/tabwriter"
 	"time"
 
@@ -24,171 +38,1383 @@ import (
 	_ "github.com/creachadair/lice/licenses/gpl"
 	_ "github.com/creachadair/lice/licenses/mit"
 	_ "github.com/creachadair/lice/licenses/mpl"
+	_ "github.com/creachadair/lice/licenses/unlicense"
+	_ "github.com/creachadair/lice/licenses/zlib"
 )
 
-var (
-	indentStyle = enumflag.New("guess", "hash", "none", "slash", "star", "sstar", "xml")
-	dateNow     = &timeflag.Value{Layout: "2006", Time: time.Now()}
-	projectName = flag.String("project", "", "Project name (if distinct from author)")
-	writeFile   = flag.String("write", "", "Write a license file at this path")
-	slug        = flag.String("L", "", "License to use (use -list for a list)")
-	doForce     = flag.Bool("f", false, "Force overwrite of existing files")
-	doEdit      = flag.Bool("edit", false, "Edit license text into non-flag argument files")
-	doList      = flag.Bool("list", false, "List available licenses")
-	viewLicense = flag.String("view", "", "View license text")
-
-	userName string
+// version is the version string reported by -version. It may be set at build
+// time with -ldflags "-X main.version=...".
+var version = "devel"
 
+var (
 	indent = map[string]licenses.Indenting{
-		"hash":  licenses.IPrefix("# "),                    // like bash, Python, Perl
-		"ps":    licenses.IPrefix("% "),                    // like PostScript or PDF
-		"slash": licenses.IPrefix("// "),                   // like C++, Go, Java
-		"star":  licenses.IComment("/*", "   ", " */"),     // like C
-		"sstar": licenses.IComment("/*", " * ", " */"),     // like C
-		"xml":   licenses.IComment("<!--", "   ", "  -->"), // like HTML, XML
+		"hash":     licenses.IPrefix("# "),                        // like bash, Python, Perl
+		"batch":    licenses.IPrefix("REM "),                      // like Windows batch files
+		"box":      licenses.IBox("#", 79),                        // like bash, Python, Perl, framed in a box
+		"c89":      licenses.ICommentCompact("/* ", "   ", " */"), // like C, as compactly as possible
+		"haskell":  licenses.IComment("{-", "   ", "-}"),          // like Haskell
+		"lua":      licenses.IPrefix("-- "),                       // like Lua
+		"luablock": licenses.IComment("--[[", "   ", "]]"),        // like Lua
+		"pascal":   licenses.IComment("(*", "   ", " *)"),         // like Pascal
+		"ps":       licenses.IPrefix("% "),                        // like PostScript or PDF
+		"slash":    licenses.IPrefix("// "),                       // like C++, Go, Java
+		"sql":      licenses.IPrefix("-- "),                       // like SQL
+		"star":     licenses.IComment("/*", "   ", " */"),         // like C
+		"sstar":    licenses.IComment("/*", " * ", " */"),         // like C
+		"istar":    licenses.ICommentInline("/* ", " * ", " */"),  // like C, with text on the first line
+		"xml":      licenses.IComment("<!--", "   ", "  -->"),     // like HTML, XML
 	}
-)
 
-func init() {
-	flag.Var(indentStyle, "i", indentStyle.Help("Indentation style"))
-	flag.Var(dateNow, "date", dateNow.Help("Copyright date for attribution"))
+	// interpIndent maps interpreter names from "#!" lines to keys of indent.
+	interpIndent = map[string]string{
+		"bash":   "hash",
+		"bun":    "slash",
+		"dash":   "hash",
+		"deno":   "slash",
+		"fish":   "hash",
+		"ksh":    "hash",
+		"lua":    "lua",
+		"node":   "slash",
+		"nodejs": "slash",
+		"perl":   "hash",
+		"php":    "slash",
+		"python": "hash",
+		"ruby":   "hash",
+		"sh":     "hash",
+		"zsh":    "hash",
+	}
 
-	u, err := user.Current()
-	if err != nil {
-		log.Panicf("Unable to determine current user: %v", err)
+	// extIndent maps file name extensions to keys of indent.
+	extIndent = map[string]string{
+		".bat":   "batch",
+		".c":     "star",
+		".cc":    "slash",
+		".cmd":   "batch",
+		".cpp":   "slash",
+		".eps":   "ps",
+		".epsf":  "ps",
+		".go":    "slash",
+		".h":     "star",
+		".hs":    "haskell",
+		".htm":   "xml",
+		".html":  "xml",
+		".ini":   "hash",
+		".java":  "slash",
+		".js":    "slash",
+		".kt":    "slash",
+		".lua":   "lua",
+		".p":     "pascal",
+		".pas":   "pascal",
+		".pdf":   "ps",
+		".php":   "slash",
+		".pl":    "hash",
+		".proto": "slash",
+		".ps":    "ps",
+		".py":    "hash",
+		".rb":    "hash",
+		".rs":    "slash",
+		".scala": "slash",
+		".sh":    "hash",
+		".sql":   "sql",
+		".swift": "slash",
+		".toml":  "hash",
+		".ts":    "slash",
+		".xhtml": "xml",
+		".yaml":  "hash",
+		".yml":   "hash",
 	}
-	flag.StringVar(&userName, "author", u.Name, "Copyright author for attribution")
 
-	flag.Usage = func() {
-		fmt.Fprintf(os.Stderr, `
-Usage: %[1]s [-list | -view <license>]
-       %[1]s -L <license> -write <file>
-       %[1]s -L <license> -edit <file1> <file2> ...
+	// nameIndent maps the base names of files, such as build files whose names
+	// have no extension, to keys of indent. It takes precedence over extIndent.
+	nameIndent = map[string]string{
+		".dockerignore":  "hash",
+		".gitattributes": "hash",
+		".gitignore":     "hash",
+		"BUILD":          "hash",
+		"BUILD.bazel":    "hash",
+		"CMakeLists.txt": "hash",
+		"Containerfile":  "hash",
+		"Dockerfile":     "hash",
+		"GNUmakefile":    "hash",
+		"Gemfile":        "hash",
+		"Makefile":       "hash",
+		"Rakefile":       "hash",
+		"WORKSPACE":      "hash",
+		"makefile":       "hash",
+	}
+
+	// langIndent maps language names for -lang to keys of indent.
+	langIndent = map[string]string{
+		"bash":       "hash",
+		"batch":      "batch",
+		"c":          "star",
+		"c++":        "slash",
+		"cpp":        "slash",
+		"go":         "slash",
+		"haskell":    "haskell",
+		"html":       "xml",
+		"java":       "slash",
+		"javascript": "slash",
+		"js":         "slash",
+		"kotlin":     "slash",
+		"lua":        "lua",
+		"pascal":     "pascal",
+		"perl":       "hash",
+		"php":        "slash",
+		"postscript": "ps",
+		"proto":      "slash",
+		"python":     "hash",
+		"ruby":       "hash",
+		"rust":       "slash",
+		"scala":      "slash",
+		"sh":         "hash",
+		"shell":      "hash",
+		"sql":        "sql",
+		"swift":      "slash",
+		"toml":       "hash",
+		"ts":         "slash",
+		"typescript": "slash",
+		"xml":        "xml",
+		"yaml":       "hash",
+	}
+)
+
+// options are the settings of the tool, as parsed from the command line by
+// the flag set returned by newFlags.
+type options struct {
+	projectName string
+	programName string
+	notation    string
+	writeFile   string
+	noticeFile  string
+	slug        string
+	doBackup    bool
+	suffix      string
+	numJobs     int
+	recursive   bool
+	dryRun      bool
+	doEdit      bool
+	doUnedit    bool
+	doCheck     bool
+	afterCmt    bool
+	atEnd       bool
+	noSecondary bool
+	doReuse     bool
+	spdxOnly    bool
+	pointerOnly bool
+	copyOnly    bool
+	doList      bool
+	listJSON    bool
+	searchFor   string
+	viewLicense string
+	viewPerFile string
+	tabWidth    int
+	langName    string
+	showVersion bool
+	detectFile  string
+	commentFile string
+	beQuiet     bool
+	listExts    bool
+	doManifest  bool
+
+	category    *enumflag.Value
+	colorMode   *enumflag.Value
+	indentStyle *enumflag.Value
+	dateNow     *timeflag.Value
+	authors     authorList
+	includes    globList
+	excludes    globList
+	doForce     forceMode
+	vars        varMap
+
+	stdout, stderr io.Writer   // where output and diagnostics are written
+	log            *log.Logger // logs errors to stderr
+}
+
+// newFlags returns a flag set that parses the command-line flags of the tool
+// into o, which it initializes with the default settings.
+func newFlags(o *options) *flag.FlagSet {
+	fs := flag.NewFlagSet(filepath.Base(os.Args[0]), flag.ContinueOnError)
+	fs.StringVar(&o.projectName, "project", "", "Project name (if distinct from author)")
+	fs.StringVar(&o.programName, "program", "", "Program name, for licenses whose per-file notices name it (e.g., GPL)")
+	fs.StringVar(&o.notation, "notation", "", `Phrase introducing a generic copyright line (default "Copyright (C)")`)
+	fs.StringVar(&o.writeFile, "write", "", "Write a license file at this path")
+	fs.StringVar(&o.noticeFile, "notice", "", "Write a NOTICE file at this path, if the license has one")
+	fs.StringVar(&o.slug, "L", "", "License to use (use -list for a list, or @path for a template file)")
+	fs.BoolVar(&o.doBackup, "backup", false, "With -edit, keep a copy of each original file")
+	fs.StringVar(&o.suffix, "backup-suffix", "~", "Suffix for the names of -backup files")
+	fs.IntVar(&o.numJobs, "j", runtime.GOMAXPROCS(0), "Number of files to edit concurrently")
+	fs.BoolVar(&o.recursive, "r", false, "Process files in directories named as arguments recursively")
+	fs.BoolVar(&o.dryRun, "n", false, "Print a diff of changes to files instead of making them")
+	fs.BoolVar(&o.doEdit, "edit", false, "Edit license text into non-flag argument files")
+	fs.BoolVar(&o.doUnedit, "unedit", false, "Remove license text from non-flag argument files")
+	fs.BoolVar(&o.doCheck, "check", false, "Check that non-flag argument files have a per-file license notice")
+	fs.BoolVar(&o.afterCmt, "after-comment", false, "With -edit, insert the notice after a comment at the top of each file")
+	fs.BoolVar(&o.atEnd, "append", false, "With -edit, insert the notice at the end of each file")
+	fs.BoolVar(&o.noSecondary, "incompatible-secondary", false, "Mark the work incompatible with secondary licenses (MPL 2.0)")
+	fs.BoolVar(&o.doReuse, "reuse", false, "Write a REUSE .license file beside each non-flag argument file")
+	fs.BoolVar(&o.spdxOnly, "spdx-only", false, "With -edit, insert only an SPDX license identifier")
+	fs.BoolVar(&o.pointerOnly, "pointer", false, "With -edit, insert only a line naming the license and referring to LICENSE")
+	fs.BoolVar(&o.copyOnly, "copyright-only", false, "With -edit, insert only a generic copyright line for any license")
+	fs.BoolVar(&o.doList, "list", false, "List available licenses")
+	fs.BoolVar(&o.listJSON, "json", false, "With -list or -search, print the list as JSON")
+	fs.StringVar(&o.searchFor, "search", "", "List licenses whose name or slug contains this text")
+	fs.StringVar(&o.viewLicense, "view", "", "View license text")
+	fs.StringVar(&o.viewPerFile, "view-perfile", "", "View the per-file notice of a license, as -edit would insert it")
+	fs.IntVar(&o.tabWidth, "tabwidth", 4, "Number of columns between tab stops in license text")
+	fs.StringVar(&o.langName, "lang", "", "Source language, for choosing an indentation style (e.g., go, python)")
+	fs.BoolVar(&o.showVersion, "version", false, "Print version information and exit")
+	fs.StringVar(&o.detectFile, "detect", "", "Report which license the text in this file matches")
+	fs.StringVar(&o.commentFile, "comment-only", "", "Print the text of this file (- for stdin) as a comment in the style chosen by -i or -lang")
+	fs.BoolVar(&o.beQuiet, "q", false, "Do not report files written or edited successfully")
+	fs.BoolVar(&o.listExts, "list-extensions", false, "List the file types whose comment style is guessed")
+	fs.BoolVar(&o.doManifest, "manifest", false, "Print a JSON manifest of the per-file licenses of non-flag argument files")
+
+	o.category = enumflag.New("all", "permissive", "weak-copyleft", "strong-copyleft", "public-domain")
+	o.colorMode = enumflag.New("auto", "always", "never")
+	o.indentStyle = enumflag.New("guess", "batch", "box", "c89", "hash", "haskell", "istar", "lua", "luablock", "none", "pascal", "slash", "sql", "star", "sstar", "xml")
+	o.dateNow = &timeflag.Value{Layout: "2006", Time: time.Now()}
+	fs.Var(o.indentStyle, "i", o.indentStyle.Help("Indentation style"))
+	fs.Var(o.category, "category", o.category.Help("With -list, list only licenses in this category"))
+	fs.Var(o.colorMode, "color", o.colorMode.Help("With -list or -search, highlight the license slugs"))
+	fs.Var(o.dateNow, "date", o.dateNow.Help("Copyright date for attribution"))
+	fs.Var(&o.authors, "author", "Copyright author for attribution (repeatable; default is the current user)")
+	fs.Var(&o.includes, "include", "With -r, process only files matching this glob (repeatable)")
+	fs.Var(&o.excludes, "exclude", "With -r, skip files matching this glob (repeatable)")
+	fs.Var(&o.doForce, "f", "Overwrite existing files whose contents differ (-f=always to overwrite regardless)")
+	fs.Var(&o.doForce, "force", "Same as -f")
+	fs.Var(&o.vars, "D", "Set a key=value variable for custom templates, as {{.Vars.key}} (repeatable)")
+
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), `
+Usage: %[1]s [-list | -search <text> | -view <license> | -detect <file>]
+       %[1]s -comment-only <file> [-i <style> | -lang <language>]
+       %[1]s -view-perfile <license> [-i <style> | -lang <language>]
+       %[1]s -L <license> -write <file> [-notice <file>]
+       %[1]s -L <license> [-write <file>] -edit <file1> <file2> ...
+       %[1]s -L <license> -unedit <file1> <file2> ...
+       %[1]s -L <license> -check <file1> <file2> ...
+       %[1]s -L <license> -reuse <file1> <file2> ...
 
 Generate license text for source code. With -list, the available license types
-are listed. With -write, the tool writes the text of a license to the specified
-file, substituting in the -author and -date information as necessary.
+are listed; with -search, only those whose name or slug contains the text. To
+use a license that is not listed, pass -L @path to read the license text from
+a template file; this uses a generic per-file notice for -edit. Such a template
+may refer to variables set with -D key=value as {{.Vars.key}}.
+With -detect, the tool reports which license the text of a file most closely
+matches, or that it matches none if it is not similar enough to any of them.
+With -view-perfile, the tool prints the per-file notice of a license as -edit
+would insert it, in the comment style chosen by -i or -lang. With
+-comment-only, the tool prints the text of a file, which need not be a
+license, as a comment in the style chosen by -i or -lang.
+
+With -write, the tool writes the text of a license to the specified file,
+substituting in the -author and -date information as necessary. If the -write
+path is a directory, the file is LICENSES/<SPDX>.txt within it, as in the
+REUSE specification. With -notice, the tool writes a NOTICE file for licenses
+that define one. An existing file is not replaced unless -f is set, and even
+then only if its contents would change; use -f=always to replace it anyway.
+Without -f, the tool asks before replacing a file if stdin is a terminal.
+These may be combined with -edit to write the license and annotate files in
+one step; the files written are not edited, and -f applies only to them.
 
 If -edit is set, any additional files named on the command line are edited in
 place to insert a comment containing a per-file license annotation, if the
-selected license type has one.
+selected license type has one. With -spdx-only, the annotation is a single
+SPDX-License-Identifier line instead, with -pointer it is a single line naming
+the license and referring to the LICENSE file, and with -copyright-only it is a
+generic copyright line, whatever the license; these also select the annotation
+that -check, -unedit, and -view-perfile look for or print. Use -notation to
+change how the generic copyright line begins, for example -notation
+"Copyright ©". The annotation is inserted at the top of each file, or with
+-after-comment, below a comment block at the top of the file, or with -append,
+at the end of the file. If a file is named "-", the tool reads from stdin and
+writes the annotated result to stdout. File names containing glob patterns
+such as "*.go" are expanded, for shells that do not do so.
+
+The comment style for the annotation is chosen by -i if it is set, otherwise
+from the source language named by -lang if it is set, otherwise guessed from
+the name of each file. For stdin, use -i or -lang since there is no name. Use
+-list-extensions to see which file names have a known comment style. Some
+licenses, such as the Creative Commons licenses, use plain text rather than a
+comment unless -i or -lang is set.
+
+If -reuse is set, any additional files named on the command line are left
+unmodified, and for each one a file with ".license" appended to its name is
+written beside it, giving the copyright and SPDX license identifier as in the
+REUSE specification (https://reuse.software). This is useful for files such as
+images that cannot contain comments.
+
+If -unedit is set, any additional files named on the command line are edited
+in place to remove the per-file license annotation, if they have one.
+
+If -check is set, any additional files named on the command line are checked
+for the per-file license annotation, ignoring the year, author, and comment
+style. Files that lack the annotation are reported, and the tool exits with a
+non-zero status if there are any.
+
+With -r, directories named on the command line are searched recursively for
+files to edit, check, or unedit. By default, this selects files whose name or
+extension has a known comment style, skipping .git and vendor directories. Use
+-include and -exclude to select files by glob patterns matching their names.
+
+With -manifest, directories named on the command line are searched as for -r,
+and the tool prints a JSON manifest giving the license whose per-file notice
+appears in each file, identified by its SPDX identifier if it has one, and the
+number of files with each license. Files with no recognized notice are listed
+with the license "unknown", and files whose notice is shared by several
+licenses, such as the generic copyright line, are listed with the license
+"ambiguous" and the licenses it may be.
+
+With -q, the tool does not report the files it writes or edits, or the ones it
+skips because they already have a notice, so that an editing run whose files
+are already up to date prints nothing. This is useful for a go:generate rule.
+
+The -list and -search output highlights license slugs when printed to a
+terminal. Use -color=always or -color=never to override this.
+
+With -n, the tool prints a unified diff of the changes -write, -edit, or
+-unedit would make to each file, rather than making them.
 
 Options:
 `, filepath.Base(os.Args[0]))
-		flag.PrintDefaults()
+		fs.PrintDefaults()
 	}
+	return fs
 }
 
+// errFailed is reported by run when some of the files it was asked to process
+// could not be processed. The failures are reported as they occur, so main
+// exits without printing it.
+var errFailed = errors.New("some files could not be processed")
+
+// minConfidence is the lowest similarity score with which -detect reports
+// that a file matches a license. Unrelated text, such as source code, scores
+// well below it, while a license with a few paragraphs changed or missing
+// still scores above it.
+const minConfidence = 0.25
+
+// errUsage is reported by run when the command-line flags are not valid. The
+// problem is reported when the flags are parsed, along with the usage text.
+var errUsage = errors.New("invalid command-line flags")
+
 func main() {
-	flag.Parse()
+	if err := run(os.Args[1:], os.Stdout, os.Stderr); errors.Is(err, errFailed) {
+		os.Exit(1)
+	} else if errors.Is(err, errUsage) {
+		os.Exit(2)
+	} else if err != nil {
+		log.Fatal(err)
+	}
+}
 
-	// If a list is requested, do that and exit early.
-	if *doList {
-		if *doEdit || *viewLicense != "" || *writeFile != "" {
-			log.Fatal("You may not combine -write, -edit, or -view with -list")
+// run parses the command-line arguments in args and carries out the operations
+// they request, writing output to out and diagnostics to errw. It returns
+// errFailed if some files could not be processed, after reporting the failures
+// to errw. Each call parses args into new options, so that run may be called
+// more than once.
+func run(args []string, out, errw io.Writer) error {
+	o := &options{stdout: out, stderr: errw, log: log.New(errw, "", log.LstdFlags)}
+	fs := newFlags(o)
+	fs.SetOutput(errw)
+	if err := fs.Parse(args); errors.Is(err, flag.ErrHelp) {
+		return nil
+	} else if err != nil {
+		return errUsage
+	}
+	if len(o.authors) == 0 {
+		u, err := user.Current()
+		if err != nil {
+			return fmt.Errorf("Unable to determine current user: %v", err)
 		}
-		fmt.Println("Available licenses:")
-		tw := tabwriter.NewWriter(os.Stdout, 8, 4, 2, ' ', tabwriter.DiscardEmptyColumns)
-		licenses.List(func(lic licenses.License) {
-			fmt.Fprint(tw, lic.Slug, "\t", lic.Name, "\t", lic.URL, "\n")
-		})
-		tw.Flush()
-		return
-	} else if *viewLicense != "" {
-		*slug = *viewLicense
-	} else if *slug == "" && *viewLicense == "" {
-		log.Fatal("You must specify a license to use with -L")
+		o.authors = authorList{u.Name}
+	}
+	if o.showVersion {
+		fmt.Fprintf(o.stdout, "%s version %s (%s)\n", filepath.Base(os.Args[0]), buildVersion(), runtime.Version())
+		return nil
+	}
+	if o.listExts {
+		return o.printExtensions()
+	}
+	if _, ok := langIndent[strings.ToLower(o.langName)]; o.langName != "" && !ok {
+		return fmt.Errorf("Unknown language %q for -lang", o.langName)
+	}
+	if o.afterCmt && o.atEnd {
+		return errors.New("You may not combine -after-comment with -append")
+	}
+	if o.tabWidth <= 0 {
+		return fmt.Errorf("Invalid -tabwidth %d; it must be positive", o.tabWidth)
+	}
+
+	// If a comment is requested, do that and exit early.
+	if o.commentFile != "" {
+		in := o.chooseIndent(nil, "-")
+		if in == nil {
+			return errors.New("You must choose a comment style with -i or -lang for -comment-only")
+		}
+		var text []byte
+		var err error
+		if o.commentFile == "-" {
+			text, err = io.ReadAll(os.Stdin)
+		} else {
+			text, err = os.ReadFile(o.commentFile)
+		}
+		if err != nil {
+			return fmt.Errorf("Reading file: %v", err)
+		}
+		_, err = io.WriteString(o.stdout, licenses.Comment(string(text), in))
+		return err
+	}
+
+	// If detection is requested, do that and exit early.
+	if o.detectFile != "" {
+		f, err := os.Open(o.detectFile)
+		if err != nil {
+			return fmt.Errorf("Opening file: %v", err)
+		}
+		lic, score, err := licenses.DetectLicense(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("Reading file: %v", err)
+		} else if lic == nil {
+			return errors.New("No licenses are registered")
+		}
+		if score < minConfidence {
+			fmt.Fprintf(o.stdout, "%s: no match (closest is %s, confidence %.0f%%)\n", o.detectFile, lic.Slug, 100*score)
+			return errFailed
+		}
+		fmt.Fprintf(o.stdout, "%s: %s (%s), confidence %.0f%%\n", o.detectFile, lic.Name, lic.Slug, 100*score)
+		return nil
+	}
+
+	// If a manifest is requested, do that and exit early.
+	if o.doManifest {
+		paths, err := o.fileArgs(fs.Args(), true)
+		if err != nil {
+			return fmt.Errorf("Finding files: %v", err)
+		}
+		if !o.printManifest(paths) {
+			return errFailed
+		}
+		return nil
 	}
 
-	lic := licenses.Lookup(*slug)
-	if lic == nil {
-		log.Fatalf("Unknown license type %q (use -list for a list)", *slug)
+	// If a list is requested, do that and exit early. Otherwise, find the
+	// license to use, which -view and -view-perfile name themselves.
+	slug := o.slug
+	if o.doList || o.searchFor != "" {
+		if o.doEdit || o.viewLicense != "" || o.viewPerFile != "" || o.writeFile != "" || o.noticeFile != "" {
+			return errors.New("You may not combine -write, -notice, -edit, or -view with -list or -search")
+		}
+		var lics []licenses.License
+		if o.searchFor != "" {
+			lics = licenses.Search(o.searchFor)
+			if len(lics) == 0 {
+				return fmt.Errorf("No licenses match %q (use -list for a list)", o.searchFor)
+			}
+			return o.printList("Matching licenses:", lics)
+		}
+		if o.category.Key() == "all" {
+			lics = licenses.Licenses()
+		} else {
+			licenses.ListByCategory(licenses.Category(o.category.Key()), func(lic licenses.License) {
+				lics = append(lics, lic)
+			})
+		}
+		return o.printList("Available licenses:", lics)
+	} else if o.viewLicense != "" && o.viewPerFile != "" {
+		return errors.New("You may not combine -view with -view-perfile")
+	} else if o.viewLicense != "" {
+		slug = o.viewLicense
+	} else if o.viewPerFile != "" {
+		slug = o.viewPerFile
+	} else if slug == "" {
+		return errors.New("You must specify a license to use with -L")
+	}
+
+	lic := licenses.Lookup(slug)
+	if path, ok := strings.CutPrefix(slug, "@"); ok {
+		text, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("Reading license template: %v", err)
+		}
+		lic = &licenses.License{
+			Name:    filepath.Base(path),
+			Slug:    slug,
+			Text:    string(text),
+			PerFile: licenses.PerFileNotice,
+		}
+	} else if lic == nil {
+		if s := licenses.Suggest(slug); len(s) != 0 {
+			return fmt.Errorf("Unknown license type %q (did you mean %s?)", slug, s[0])
+		}
+		return fmt.Errorf("Unknown license type %q (use -list for a list)", slug)
 	}
 
 	cfg := &licenses.Config{
-		Author:  userName,
-		Project: *projectName,
-		Time:    dateNow.Time,
+		Author:                o.authors[0],
+		Authors:               o.authors,
+		Project:               o.projectName,
+		Program:               o.programName,
+		Time:                  o.dateNow.Time,
+		TabWidth:              o.tabWidth,
+		AfterComment:          o.afterCmt,
+		AtEnd:                 o.atEnd,
+		IncompatibleSecondary: o.noSecondary,
+		Notation:              o.notation,
+		Vars:                  o.vars,
+	}
+	if o.doBackup {
+		cfg.Backup = o.suffix
+	}
+
+	// Choose the per-file notice, for -view-perfile, -check, -unedit, and -edit.
+	if nset(o.spdxOnly, o.pointerOnly, o.copyOnly) > 1 {
+		return errors.New("You may not combine -spdx-only, -pointer, or -copyright-only")
+	}
+	if o.spdxOnly {
+		if lic.SPDX == "" {
+			return fmt.Errorf("The %s has no SPDX identifier", lic.Name)
+		}
+		lic.PerFile = licenses.SPDXNotice
+	} else if o.pointerOnly {
+		lic.PerFile = licenses.PointerNotice
+	} else if o.copyOnly {
+		lic.PerFile = licenses.PerFileNotice
 	}
 
 	// View a license.
-	if *viewLicense != "" {
-		if err := lic.WriteText(os.Stdout, cfg); err != nil {
-			log.Fatalf("Viewing license: %v", err)
+	if o.viewLicense != "" {
+		if err := lic.WriteText(o.stdout, cfg); err != nil {
+			return fmt.Errorf("Viewing license: %v", err)
+		}
+	}
+
+	// View the per-file notice of a license. The comment style is chosen as
+	// for stdin, since there is no file name to guess from.
+	if o.viewPerFile != "" {
+		if lic.PerFile == "" {
+			o.inform("The %s has no per-file notice to view [skipped]\n", lic.Name)
+		} else if err := lic.WritePerFile(o.stdout, cfg, o.chooseIndent(lic, "-")); err != nil {
+			return fmt.Errorf("Viewing per-file notice: %v", err)
+		}
+	}
+
+	// Write a license to a file. If this fails, keep going so that -edit in
+	// the same invocation still has a chance to run, but report the failure
+	// in the exit status.
+	var hasErr bool
+	var written []string
+	if o.writeFile != "" {
+		path, err := o.licensePath(lic, o.writeFile)
+		if err == nil {
+			err = o.writeOutput(path, func(w io.Writer) error {
+				return lic.WriteText(w, cfg)
+			})
+		}
+		if errors.Is(err, errUnchanged) {
+			o.inform("File %s is up to date [skipped]\n", path)
+		} else if err != nil {
+			o.log.Printf("Writing license file: %v", err)
+			hasErr = true
+		} else if !o.dryRun {
+			o.inform("Wrote %s to %s\n", lic.Name, path)
+		}
+		written = append(written, path)
+	}
+
+	// Write a notice to a file, if the license has one.
+	if o.noticeFile != "" {
+		if lic.Notice == "" {
+			o.inform("The %s does not define a NOTICE file [skipped]\n", lic.Name)
+		} else if err := o.writeOutput(o.noticeFile, func(w io.Writer) error {
+			return lic.WriteNotice(w, cfg)
+		}); errors.Is(err, errUnchanged) {
+			o.inform("File %s is up to date [skipped]\n", o.noticeFile)
+		} else if err != nil {
+			o.log.Printf("Writing notice file: %v", err)
+			hasErr = true
+		} else if !o.dryRun {
+			o.inform("Wrote %s notice to %s\n", lic.Name, o.noticeFile)
+		}
+		written = append(written, o.noticeFile)
+	}
+
+	paths, err := o.fileArgs(fs.Args(), o.recursive)
+	if err != nil {
+		return fmt.Errorf("Finding files: %v", err)
+	}
+	// Do not edit the files written above, if they were named as arguments.
+	paths = slices.DeleteFunc(paths, func(path string) bool {
+		return slices.ContainsFunc(written, func(w string) bool { return sameFile(path, w) })
+	})
+
+	// Check for license tags in other files.
+	if o.doCheck {
+		if o.doEdit {
+			return errors.New("You may not combine -check with -edit")
+		} else if lic.PerFile == "" {
+			return fmt.Errorf("The %s has no per-file notice to check for", lic.Name)
 		}
+		if !o.checkFiles(lic, paths) || hasErr {
+			return errFailed
+		}
+		return nil
+	}
+
+	// Remove license tags from other files.
+	if o.doUnedit {
+		if o.doEdit || o.doCheck {
+			return errors.New("You may not combine -unedit with -edit or -check")
+		} else if lic.PerFile == "" {
+			return fmt.Errorf("The %s has no per-file notice to remove", lic.Name)
+		}
+		if !o.uneditFiles(lic, paths) || hasErr {
+			return errFailed
+		}
+		return nil
 	}
 
-	// Write a license to a file.
-	if *writeFile != "" {
-		oflag := os.O_RDWR | os.O_CREATE | os.O_TRUNC
-		if !*doForce {
-			oflag |= os.O_EXCL
+	// Write license tags beside other files.
+	if o.doReuse {
+		if o.doEdit || o.doCheck {
+			return errors.New("You may not combine -reuse with -edit or -check")
+		} else if lic.SPDX == "" {
+			return fmt.Errorf("The %s has no SPDX identifier", lic.Name)
+		}
+		if !o.reuseFiles(lic, cfg, paths) || hasErr {
+			return errFailed
 		}
-		f, err := os.OpenFile(*writeFile, oflag, 0644)
+		return nil
+	}
+
+	// Edit license tags into other files, if available.
+	if !o.doEdit {
+		paths = nil
+	} else if lic.PerFile == "" && len(paths) != 0 {
+		o.inform("The %s has no per-file notice to add [skipped]\n", lic.Name)
+		// Pass stdin through unchanged, as for an input already licensed.
+		if slices.Contains(paths, "-") {
+			if _, err := io.Copy(o.stdout, os.Stdin); err != nil {
+				return fmt.Errorf("Copying stdin: %v", err)
+			}
+		}
+		paths = nil
+	} else if slices.Contains(paths, "-") && o.indentStyle.Key() == "guess" && o.langName == "" && lic.DefaultIndent == nil {
+		// There is no file name to guess the comment style of stdin from.
+		return errors.New("You must choose a comment style with -i or -lang to edit stdin")
+	}
+	// Edit files concurrently, but report the results in order. The notice is
+	// rendered once for all the files.
+	var rnd *licenses.Renderer
+	if len(paths) != 0 {
+		if rnd, err = lic.Compile(cfg); err != nil {
+			return fmt.Errorf("Rendering license: %v", err)
+		}
+	}
+	results := o.editFiles(lic, rnd, paths)
+	var nEdited, nSkipped, nFailed int
+	for i, path := range paths {
+		o.stdout.Write(results[i].output)
+		err := results[i].err
+		if errors.Is(err, licenses.ErrAlreadyLicensed) {
+			nSkipped++
+		} else if err != nil {
+			nFailed++
+		} else {
+			nEdited++
+		}
+		if path == "-" {
+			if err != nil && !errors.Is(err, licenses.ErrAlreadyLicensed) {
+				o.log.Printf("Editing stdin: %v", err)
+			}
+			continue
+		}
+		if errors.Is(err, licenses.ErrAlreadyLicensed) {
+			o.inform("File %s already has a license notice [skipped]\n", path)
+		} else if err != nil {
+			o.log.Printf("Editing file: %v", err)
+		} else if !o.dryRun {
+			o.inform("Added %s to %s\n", lic.Name, path)
+		}
+	}
+	if o.recursive || len(paths) > 1 {
+		format := "Annotated %s, skipped %d (already licensed), %s\n"
+		if o.dryRun {
+			format = "Would annotate %s, skip %d (already licensed), %s\n"
+		}
+		o.inform(format, plural(nEdited, "file"), nSkipped, plural(nFailed, "error"))
+	}
+	hasErr = hasErr || nFailed != 0
+
+	if hasErr {
+		return errFailed
+	}
+	return nil
+}
+
+// An editResult records the outcome of editing a single file.
+type editResult struct {
+	output []byte // data to be written to stdout
+	err    error
+}
+
+// editFiles edits the per-file notice for lic, as rendered by rnd, into each of
+// the specified files, using up to -j concurrent workers. It returns the
+// results in the same order as paths.
+func (o *options) editFiles(lic *licenses.License, rnd *licenses.Renderer, paths []string) []editResult {
+	results := make([]editResult, len(paths))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range max(1, min(o.numJobs, len(paths))) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				var buf bytes.Buffer
+				err := o.editFile(lic, rnd, paths[i], &buf)
+				results[i] = editResult{output: buf.Bytes(), err: err}
+			}
+		}()
+	}
+	for i := range paths {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return results
+}
+
+// editFile edits the per-file notice for lic, as rendered by rnd, into the
+// file at path. If -n is set, it writes a diff of the change to w instead. If
+// path is "-", it reads stdin and writes the edited result to w.
+func (o *options) editFile(lic *licenses.License, rnd *licenses.Renderer, path string, w io.Writer) error {
+	if path == "-" {
+		return rnd.Edit(os.Stdin, w, o.chooseIndent(lic, path))
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if !o.dryRun {
+		return rnd.EditFile(f, o.chooseIndent(lic, path))
+	}
+	src, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+	out, err := rnd.EditBytes(src, o.chooseIndent(lic, path))
+	if err != nil {
+		return err
+	}
+	writeDiff(w, path, path, src, out)
+	return nil
+}
+
+// checkFiles reports whether each of the specified files has the per-file
+// notice for lic, logging the names of those that do not.
+func (o *options) checkFiles(lic *licenses.License, paths []string) bool {
+	ok := true
+	for _, path := range paths {
+		f, err := os.Open(path)
 		if err != nil {
-			log.Fatalf("Opening license file: %v", err)
+			o.log.Printf("Opening file: %v", err)
+			ok = false
+			continue
 		}
-		err = lic.WriteText(f, cfg)
-		cerr := f.Close()
+		has, err := lic.HasNotice(f, o.chooseIndent(lic, path))
+		f.Close()
 		if err != nil {
-			log.Fatalf("Writing license file: %v", err)
-		} else if cerr != nil {
-			log.Fatalf("Closing license file: %v", cerr)
+			o.log.Printf("Checking file: %v", err)
+			ok = false
+		} else if !has {
+			fmt.Fprintf(o.stderr, "%s: missing %s notice\n", path, lic.Name)
+			ok = false
 		}
-		fmt.Fprintf(os.Stderr, "Wrote %s to %s\n", lic.Name, *writeFile)
 	}
+	return ok
+}
 
-	// Edit license tags into other files, if available.
-	if !*doEdit || flag.NArg() == 0 || lic.PerFile == "" {
-		return
+// uneditFiles removes the per-file notice for lic from each of the specified
+// files, and reports whether this succeeded for all of them.
+func (o *options) uneditFiles(lic *licenses.License, paths []string) bool {
+	ok := true
+	for _, path := range paths {
+		err := o.uneditFile(lic, path)
+		if errors.Is(err, licenses.ErrNoNotice) {
+			o.inform("File %s has no license notice [skipped]\n", path)
+		} else if err != nil {
+			o.log.Printf("Editing file: %v", err)
+			ok = false
+		} else if !o.dryRun {
+			o.inform("Removed %s from %s\n", lic.Name, path)
+		}
 	}
-	hasErr := false
-	for _, path := range flag.Args() {
+	return ok
+}
+
+// uneditFile removes the per-file notice for lic from the file at path. If -n
+// is set, it prints a diff of the change to stdout instead.
+func (o *options) uneditFile(lic *licenses.License, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if !o.dryRun {
+		return lic.RemoveNoticeFile(f, o.chooseIndent(lic, path))
+	}
+	src, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+	out, err := lic.RemoveNotice(src, o.chooseIndent(lic, path))
+	if err != nil {
+		return err
+	}
+	writeDiff(o.stdout, path, path, src, out)
+	return nil
+}
+
+// reuseFiles writes a REUSE sidecar file for each of the given paths, whose
+// name is the path with ".license" appended. It reports whether all the files
+// were written successfully.
+func (o *options) reuseFiles(lic *licenses.License, cfg *licenses.Config, paths []string) bool {
+	ok := true
+	for _, path := range paths {
+		side := path + ".license"
+		if err := o.writeOutput(side, func(w io.Writer) error {
+			return lic.WriteSidecar(w, cfg)
+		}); errors.Is(err, errUnchanged) {
+			o.inform("File %s is up to date [skipped]\n", side)
+		} else if err != nil {
+			o.log.Printf("Writing license file: %v", err)
+			ok = false
+		} else if !o.dryRun {
+			o.inform("Wrote %s to %s\n", lic.Name, side)
+		}
+	}
+	return ok
+}
+
+// nset returns the number of flags that are true.
+func nset(flags ...bool) (n int) {
+	for _, f := range flags {
+		if f {
+			n++
+		}
+	}
+	return n
+}
+
+// plural returns n followed by noun, pluralized if n != 1.
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// inform prints an informational message to stderr, unless -q is set.
+func (o *options) inform(format string, args ...any) {
+	if !o.beQuiet {
+		fmt.Fprintf(o.stderr, format, args...)
+	}
+}
+
+// printManifest prints to stdout a JSON manifest of the licenses whose
+// per-file notices appear in the given files, along with a count of the
+// files for each license. Files without a recognized notice are listed as
+// "unknown", and files whose notice is shared by several licenses are listed
+// as "ambiguous", with the licenses it may be. It reports whether all the
+// files could be read.
+func (o *options) printManifest(paths []string) bool {
+	type entry struct {
+		Path       string   `json:"path"`
+		License    string   `json:"license"`
+		Name       string   `json:"name,omitempty"`
+		Candidates []string `json:"candidates,omitempty"`
+	}
+	var out struct {
+		Files    []entry        `json:"files"`
+		Licenses map[string]int `json:"licenses"`
+	}
+	out.Files = []entry{}
+	out.Licenses = make(map[string]int)
+	ok := true
+	for _, path := range paths {
 		f, err := os.Open(path)
 		if err != nil {
-			log.Printf("Opening file: %v [skipped]", err)
-			hasErr = true
+			o.log.Printf("Opening file: %v [skipped]", err)
+			ok = false
 			continue
 		}
-		func() {
-			defer f.Close()
-			if err := lic.EditFile(f, cfg, chooseIndent(path)); err != nil {
-				log.Printf("Editing file: %v", err)
-				hasErr = true
-			} else {
-				fmt.Fprintf(os.Stderr, "Added %s to %s\n", lic.Name, path)
+		lics, err := licenses.DetectNotices(f, o.chooseIndent(nil, path))
+		f.Close()
+		if err != nil {
+			o.log.Printf("Reading file: %v [skipped]", err)
+			ok = false
+			continue
+		}
+		e := entry{Path: path, License: "unknown"}
+		if len(lics) == 1 {
+			e.License, e.Name = cmp.Or(lics[0].SPDX, lics[0].Slug), lics[0].Name
+		} else if len(lics) > 1 {
+			e.License = "ambiguous"
+			for _, lic := range lics {
+				e.Candidates = append(e.Candidates, cmp.Or(lic.SPDX, lic.Slug))
 			}
-		}()
+		}
+		out.Files = append(out.Files, e)
+		out.Licenses[e.License]++
+	}
+	enc := json.NewEncoder(o.stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		o.log.Printf("Encoding manifest: %v", err)
+		return false
 	}
+	return ok
+}
 
-	if hasErr {
-		os.Exit(1)
+// printList prints a table of the given licenses to stdout, preceded by the
+// given title. If -json is set, it prints a JSON array instead.
+func (o *options) printList(title string, lics []licenses.License) error {
+	if o.listJSON {
+		type entry struct {
+			Slug     string `json:"slug"`
+			Name     string `json:"name"`
+			URL      string `json:"url,omitempty"`
+			SPDX     string `json:"spdx,omitempty"`
+			Category string `json:"category,omitempty"`
+		}
+		out := make([]entry, len(lics))
+		for i, lic := range lics {
+			out[i] = entry{
+				Slug:     lic.Slug,
+				Name:     lic.Name,
+				URL:      lic.URL,
+				SPDX:     lic.SPDX,
+				Category: string(lic.Category),
+			}
+		}
+		enc := json.NewEncoder(o.stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(out); err != nil {
+			return fmt.Errorf("Encoding list: %w", err)
+		}
+		return nil
+	}
+	fmt.Fprintln(o.stdout, title)
+	tw := tabwriter.NewWriter(o.stdout, 8, 4, 2, ' ', tabwriter.DiscardEmptyColumns)
+	for _, lic := range lics {
+		// Every slug gets the same escapes, so the columns still line up.
+		name := lic.Slug
+		if o.useColor() {
+			name = "\x1b[1m" + name + "\x1b[0m"
+		}
+		fmt.Fprint(tw, name, "\t", lic.Name, "\t", lic.URL, "\n")
+	}
+	return tw.Flush()
+}
+
+// useColor reports whether output to stdout should be highlighted, according
+// to -color. By default, it is highlighted only if stdout is a terminal.
+func (o *options) useColor() bool {
+	switch o.colorMode.Key() {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	return isTerminal(o.stdout)
+}
+
+// isTerminal reports whether w is a file attached to a terminal. This is
+// approximate: Any character device other than the null device counts.
+func isTerminal(w any) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	if err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+	null, err := os.Stat(os.DevNull)
+	return err != nil || !os.SameFile(fi, null)
+}
+
+// stdinLines reads responses to confirm from stdin.
+var stdinLines *bufio.Reader
+
+// confirm asks the user a yes-or-no question, and reports whether they said
+// yes. If stdin is not a terminal, it does not ask, and reports false, so that
+// scripts do not wait for an answer that will not come.
+func (o *options) confirm(format string, args ...any) bool {
+	if !isTerminal(os.Stdin) {
+		return false
+	}
+	if stdinLines == nil {
+		stdinLines = bufio.NewReader(os.Stdin)
+	}
+	fmt.Fprintf(o.stderr, format+" [y/N] ", args...)
+	line, _ := stdinLines.ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+// printExtensions prints to stdout the tables used by guessIndent to choose
+// a comment style from the name or interpreter of a file.
+func (o *options) printExtensions() error {
+	tw := tabwriter.NewWriter(o.stdout, 8, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "File names:")
+	for _, name := range slices.Sorted(maps.Keys(nameIndent)) {
+		fmt.Fprint(tw, name, "\t", nameIndent[name], "\n")
+	}
+	fmt.Fprintln(tw, "\nFile extensions:")
+	for _, ext := range slices.Sorted(maps.Keys(extIndent)) {
+		fmt.Fprint(tw, ext, "\t", extIndent[ext], "\n")
+	}
+	fmt.Fprintln(tw, "\nInterpreters (for files with no extension):")
+	for _, name := range slices.Sorted(maps.Keys(interpIndent)) {
+		fmt.Fprint(tw, name, "\t", interpIndent[name], "\n")
+	}
+	fmt.Fprintln(tw, "(other)\thash")
+	return tw.Flush()
+}
+
+// buildVersion returns the version string for the program. If none was set
+// at build time, it uses the module version recorded in the binary, if any.
+func buildVersion() string {
+	if version == "devel" {
+		if bi, ok := debug.ReadBuildInfo(); ok && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+			return bi.Main.Version
+		}
+	}
+	return version
+}
+
+// authorList is a repeatable flag that collects author names.
+type authorList []string
+
+func (a *authorList) String() string { return strings.Join(*a, ", ") }
+
+func (a *authorList) Set(s string) error {
+	*a = append(*a, s)
+	return nil
+}
+
+// forceMode is a flag that controls whether existing files are overwritten. As
+// a boolean flag, it enables overwriting files whose contents would change;
+// with the value "always", files are overwritten even if they would not.
+type forceMode struct {
+	set, always bool
+}
+
+func (f *forceMode) String() string {
+	if f.always {
+		return "always"
+	}
+	return strconv.FormatBool(f.set)
+}
+
+func (f *forceMode) IsBoolFlag() bool { return true }
+
+func (f *forceMode) Set(s string) error {
+	if s == "always" {
+		f.set, f.always = true, true
+		return nil
+	}
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return errors.New(`value must be a boolean or "always"`)
+	}
+	f.set, f.always = v, false
+	return nil
+}
+
+// varMap is a repeatable flag that collects key=value template variables.
+type varMap map[string]string
+
+func (v varMap) String() string {
+	var pairs []string
+	for _, key := range slices.Sorted(maps.Keys(v)) {
+		pairs = append(pairs, key+"="+v[key])
+	}
+	return strings.Join(pairs, ", ")
+}
+
+func (v *varMap) Set(s string) error {
+	key, value, ok := strings.Cut(s, "=")
+	if !ok || key == "" {
+		return errors.New("value must have the form key=value")
+	}
+	if *v == nil {
+		*v = make(varMap)
+	}
+	(*v)[key] = value
+	return nil
+}
+
+// globList is a repeatable flag that collects glob patterns.
+type globList []string
+
+func (g *globList) String() string { return strings.Join(*g, ", ") }
+
+func (g *globList) Set(s string) error {
+	if _, err := filepath.Match(s, ""); err != nil {
+		return err
+	}
+	*g = append(*g, s)
+	return nil
+}
+
+// match reports whether the base name of path matches any of the patterns.
+func (g globList) match(path string) bool {
+	base := filepath.Base(path)
+	for _, pat := range g {
+		if ok, _ := filepath.Match(pat, base); ok {
+			return true
+		}
 	}
+	return false
 }
 
-// chooseIndent picks a suitable indenting rule for a file. If an indenting
-// rule was specified by the user, use that; otherwise if the user asked us to
-// guess, do so based on its file extension. If no indenting rule can be
-// inferred, fall back to undecorated text.
-func chooseIndent(path string) licenses.Indenting {
-	in, ok := indent[indentStyle.Key()]
+// fileArgs returns the list of files to process for the given arguments.
+// Glob patterns among the arguments are expanded first, as by expandGlobs.
+// Unless recursive is true, this is the arguments themselves. Otherwise, as
+// for -r, directories are replaced by the regular files they contain,
+// recursively, subject to the -include and -exclude patterns; other arguments
+// are kept as given.
+func (o *options) fileArgs(args []string, recursive bool) ([]string, error) {
+	args = expandGlobs(args)
+	if !recursive {
+		return args, nil
+	}
+	var paths []string
+	for _, arg := range args {
+		fi, err := os.Stat(arg)
+		if arg == "-" || err != nil || !fi.IsDir() {
+			paths = append(paths, arg) // report errors when the file is used
+			continue
+		}
+		err = filepath.WalkDir(arg, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			} else if d.IsDir() {
+				if path != arg && skipDirs[d.Name()] {
+					return filepath.SkipDir
+				}
+				return nil
+			} else if !d.Type().IsRegular() || o.excludes.match(path) {
+				return nil
+			}
+			if len(o.includes) != 0 {
+				if o.includes.match(path) {
+					paths = append(paths, path)
+				}
+			} else if _, ok := nameIndent[d.Name()]; ok || (filepath.Ext(path) != "" && guessIndent(path) != nil) {
+				paths = append(paths, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return paths, nil
+}
+
+// expandGlobs replaces each argument that contains glob metacharacters with
+// the names of the files it matches, for platforms whose shell does not do
+// this. An argument that names an existing file, or matches nothing, is kept
+// as written.
+func expandGlobs(args []string) []string {
+	var out []string
+	for _, arg := range args {
+		if strings.ContainsAny(arg, "*?[") {
+			if _, err := os.Lstat(arg); err != nil {
+				if m, err := filepath.Glob(arg); err == nil && len(m) != 0 {
+					out = append(out, m...)
+					continue
+				}
+			}
+		}
+		out = append(out, arg)
+	}
+	return out
+}
+
+// skipDirs are the names of directories that -r does not descend into.
+var skipDirs = map[string]bool{".git": true, "vendor": true}
+
+// licensePath returns the path of the file to which -write should write the
+// text of lic. If path names a directory, the file is LICENSES/<SPDX>.txt in
+// that directory, following the REUSE specification, and the LICENSES
+// directory is created if necessary. Otherwise the file is path itself.
+func (o *options) licensePath(lic *licenses.License, path string) (string, error) {
+	if fi, err := os.Stat(path); err != nil || !fi.IsDir() {
+		return path, nil
+	} else if lic.SPDX == "" {
+		return "", fmt.Errorf("the %s has no SPDX identifier to name a file in %s", lic.Name, path)
+	}
+	dir := filepath.Join(path, "LICENSES")
+	if !o.dryRun {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return "", err
+		}
+	}
+	return filepath.Join(dir, lic.SPDX+".txt"), nil
+}
+
+// sameFile reports whether paths a and b name the same file. Paths that do
+// not exist are compared by name.
+func sameFile(a, b string) bool {
+	if filepath.Clean(a) == filepath.Clean(b) {
+		return true
+	}
+	ai, aerr := os.Stat(a)
+	bi, berr := os.Stat(b)
+	return aerr == nil && berr == nil && os.SameFile(ai, bi)
+}
+
+// errUnchanged is reported by writeOutput when it leaves a file alone because
+// its contents are already as they would be written.
+var errUnchanged = errors.New("file is unchanged")
+
+// errExists reports that path exists and was not overwritten.
+func errExists(path string) error {
+	return fmt.Errorf("refusing to overwrite existing %s; pass -f to force", path)
+}
+
+// writeOutput creates or replaces the file at path and calls write to
+// populate its contents. Unless -f is set, it is an error if path exists,
+// unless the user confirms that it should be overwritten when asked.
+// If -f is set, an existing file whose contents would not change is left
+// alone, and writeOutput reports errUnchanged; with -f=always it is rewritten.
+// If -n is set, it prints a diff of the change to stdout instead.
+//
+// The contents are rendered before the file is written, so that if write fails
+// an existing file is not disturbed, and the file is replaced atomically, as
+// described by licenses.WriteFile.
+func (o *options) writeOutput(path string, write func(io.Writer) error) error {
+	if o.dryRun {
+		return o.diffOutput(path, write)
+	}
+	var buf bytes.Buffer
+	if err := write(&buf); err != nil {
+		return err
+	}
+	if o.doForce.set && !o.doForce.always {
+		if old, err := os.ReadFile(path); err == nil && bytes.Equal(old, buf.Bytes()) {
+			return errUnchanged
+		}
+	}
+	fi, err := os.Stat(path)
+	if err == nil && !o.doForce.set && !o.confirm("Overwrite existing %s?", path) {
+		return errExists(path)
+	} else if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	perm := fs.FileMode(0644)
+	if fi != nil {
+		perm = fi.Mode().Perm()
+	}
+	return licenses.WriteFile(path, buf.Bytes(), perm)
+}
+
+// diffOutput calls write to generate the new contents for the file at path,
+// and prints a diff from the current contents to stdout.
+func (o *options) diffOutput(path string, write func(io.Writer) error) error {
+	var buf bytes.Buffer
+	if err := write(&buf); err != nil {
+		return err
+	}
+	oldName := path
+	old, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		oldName = "/dev/null"
+	} else if err != nil {
+		return err
+	} else if !o.doForce.set {
+		return errExists(path)
+	}
+	writeDiff(o.stdout, oldName, path, old, buf.Bytes())
+	return nil
+}
+
+// chooseIndent picks a suitable indenting rule for the notice of lic in a
+// file. If an indenting rule was specified by the user, use that; otherwise if
+// the user asked us to guess, use the rule for the language named by -lang, if
+// any, or else the default indenting of lic, if it has one, or else guess based
+// on its file extension. If no indenting rule can be inferred, fall back to
+// undecorated text. The license may be nil if it is not known.
+func (o *options) chooseIndent(lic *licenses.License, path string) licenses.Indenting {
+	in, ok := indent[o.indentStyle.Key()]
 	if ok {
 		return in
-	} else if indentStyle.Key() != "guess" {
+	} else if o.indentStyle.Key() != "guess" {
 		return nil
-	}
-	switch filepath.Ext(path) {
-	case "", ".sh", ".py", ".pl", ".rb": // N.B. includes no extension
-		return indent["hash"]
-	case ".cc", ".cpp", ".go", ".java", ".js", ".proto":
-		return indent["slash"]
-	case ".c", ".h":
-		return indent["star"]
-	case ".htm", ".html", ".xhtml":
-		return indent["xml"]
-	case ".ps", ".eps", ".epsf", ".pdf":
-		return indent["ps"]
-	default:
+	} else if o.langName != "" {
+		return indent[langIndent[strings.ToLower(o.langName)]]
+	} else if lic != nil && lic.DefaultIndent != nil {
+		return lic.DefaultIndent
+	} else if path == "-" {
 		return nil
 	}
+	return guessIndent(path)
+}
+
+// interpreter returns the name of the interpreter named by the "#!" line at the
+// beginning of the file at path, without any version suffix, or "" if the file
+// cannot be read or does not begin with such a line. For example, the name for
+// "#!/usr/bin/env python3" is "python".
+func interpreter(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	line, err := bufio.NewReader(f).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return ""
+	}
+	rest, ok := strings.CutPrefix(line, "#!")
+	if !ok {
+		return ""
+	}
+	args := strings.Fields(rest)
+	if len(args) != 0 && filepath.Base(args[0]) == "env" {
+		args = args[1:]
+		for len(args) != 0 && strings.HasPrefix(args[0], "-") {
+			args = args[1:] // skip options to env, e.g., -S
+		}
+	}
+	if len(args) == 0 {
+		return ""
+	}
+	return strings.TrimRight(filepath.Base(args[0]), "0123456789.")
+}
+
+// guessIndent guesses an indenting rule for a file based on its base name, as
+// given by nameIndent, or else its extension, as given by extIndent. For a
+// file with no extension, it uses the interpreter named by the "#!" line of
+// the file, if it has one, as given by interpIndent, or else the hash style.
+// It returns nil for a file whose extension is not known.
+func guessIndent(path string) licenses.Indenting {
+	if key, ok := nameIndent[filepath.Base(path)]; ok {
+		return indent[key]
+	}
+	ext := filepath.Ext(path)
+	if ext != "" {
+		return indent[extIndent[ext]]
+	} else if key, ok := interpIndent[interpreter(path)]; ok {
+		return indent[key]
+	}
+	return indent["hash"]
 }
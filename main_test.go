@@ -0,0 +1,202 @@
+// Copyright (C) 2018, Michael J. Fromberger
+// All Rights Reserved.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/creachadair/lice/licenses"
+)
+
+// runLice calls run with args, and returns what it wrote to stdout.
+func runLice(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	var out, errw bytes.Buffer
+	err := run(append([]string{"-author", "Alice", "-date", "2024"}, args...), &out, &errw)
+	if err != nil {
+		t.Logf("run %q: %v\nstderr:\n%s", args, err, errw.String())
+	}
+	return out.String(), err
+}
+
+// writeFile writes a file with the given contents in dir, and returns its path.
+func writeFile(t *testing.T, dir, name, text string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+		t.Fatalf("Writing file: %v", err)
+	}
+	return path
+}
+
+// readFile returns the contents of the file at path.
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Reading file: %v", err)
+	}
+	return string(data)
+}
+
+func TestRunRepeated(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "a.go", "package a\n")
+
+	// Each call to run must start from the default settings, so that the flags
+	// of one call do not leak into the next.
+	tests := []struct {
+		args    []string
+		wantErr error // nil means success
+	}{
+		{[]string{"-L", "apache", "-spdx-only", "-edit", path}, nil},
+		{[]string{"-L", "apache", "-spdx-only", "-check", path}, nil},
+		{[]string{"-L", "apache", "-check", path}, errFailed},
+		{[]string{"-L", "mit", "-spdx-only", "-check", path}, errFailed},
+		{[]string{"-view", "mit"}, nil},
+		{[]string{"-edit", path}, errors.New("You must specify a license to use with -L")},
+		{[]string{"-L", "apache", "-spdx-only", "-n", "-unedit", path}, nil},
+		{[]string{"-L", "apache", "-spdx-only", "-check", path}, nil},
+		{[]string{"-L", "apache", "-spdx-only", "-unedit", path}, nil},
+		{[]string{"-L", "apache", "-spdx-only", "-check", path}, errFailed},
+		{[]string{"-no-such-flag"}, errUsage},
+		{[]string{"-L", "mit", "-append", "-edit", path}, nil},
+		{[]string{"-L", "mit", "-unedit", path}, nil},
+		{[]string{"-L", "mit", "-check", path}, errFailed},
+	}
+	for _, test := range tests {
+		_, err := runLice(t, test.args...)
+		if test.wantErr == nil && err != nil {
+			t.Errorf("run %q: unexpected error: %v", test.args, err)
+		} else if test.wantErr != nil && (err == nil || err.Error() != test.wantErr.Error()) {
+			t.Errorf("run %q: got error %v, want %v", test.args, err, test.wantErr)
+		}
+	}
+	if got, want := readFile(t, path), "package a\n"; got != want {
+		t.Errorf("After edit and unedit: got %q, want %q", got, want)
+	}
+}
+
+func TestDryRunUnedit(t *testing.T) {
+	dir := t.TempDir()
+	const text = "// Copyright (C) 2024 Alice. All Rights Reserved.\n\npackage a\n"
+	path := writeFile(t, dir, "a.go", text)
+
+	out, err := runLice(t, "-L", "mit", "-n", "-unedit", path)
+	if err != nil {
+		t.Fatalf("run: unexpected error: %v", err)
+	}
+	if !strings.Contains(out, "-// Copyright (C) 2024 Alice.") {
+		t.Errorf("Diff does not remove the notice:\n%s", out)
+	}
+	if got := readFile(t, path); got != text {
+		t.Errorf("File was modified with -n: got %q, want %q", got, text)
+	}
+}
+
+func TestEditStdinWithoutNotice(t *testing.T) {
+	const text = "package a\n"
+	stdin, err := os.Open(writeFile(t, t.TempDir(), "a.go", text))
+	if err != nil {
+		t.Fatalf("Opening file: %v", err)
+	}
+	defer stdin.Close()
+	defer func(f *os.File) { os.Stdin = f }(os.Stdin)
+	os.Stdin = stdin
+
+	// A license without a per-file notice leaves the input as it was.
+	out, err := runLice(t, "-L", "unlicense", "-edit", "-")
+	if err != nil {
+		t.Fatalf("run: unexpected error: %v", err)
+	}
+	if out != text {
+		t.Errorf("run -edit -: got %q, want %q", out, text)
+	}
+}
+
+func TestEditSummary(t *testing.T) {
+	dir := t.TempDir()
+	a := writeFile(t, dir, "a.go", "package a\n")
+	b := writeFile(t, dir, "b.go", "package b\n")
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"-n"}, "Would annotate 2 files, skip 0 (already licensed), 0 errors\n"},
+		{nil, "Annotated 2 files, skipped 0 (already licensed), 0 errors\n"},
+	}
+	for _, test := range tests {
+		var out, errw bytes.Buffer
+		args := append([]string{"-author", "Alice", "-L", "mit", "-edit"}, test.args...)
+		if err := run(append(args, a, b), &out, &errw); err != nil {
+			t.Fatalf("run %q: unexpected error: %v", args, err)
+		}
+		if got := errw.String(); !strings.HasSuffix(got, test.want) {
+			t.Errorf("run %q: got summary %q, want %q", args, got, test.want)
+		}
+	}
+}
+
+func TestDetect(t *testing.T) {
+	dir := t.TempDir()
+	licText, err := runLice(t, "-view", "mit")
+	if err != nil {
+		t.Fatalf("run: unexpected error: %v", err)
+	}
+	tests := []struct {
+		text, want string
+		wantErr    error
+	}{
+		{licText, "MIT License (Expat) (mit-expat), confidence 100%", nil},
+		{"package main\n\nfunc main() { println(\"hello, world\") }\n", "no match", errFailed},
+	}
+	for _, test := range tests {
+		path := writeFile(t, dir, "text", test.text)
+		out, err := runLice(t, "-detect", path)
+		if err != test.wantErr {
+			t.Errorf("run -detect: got error %v, want %v", err, test.wantErr)
+		}
+		if !strings.Contains(out, test.want) {
+			t.Errorf("run -detect: got %q, want %q", out, test.want)
+		}
+	}
+}
+
+func TestGuessIndent(t *testing.T) {
+	tests := []struct {
+		path, want string // want is a key of indent
+	}{
+		{"lib.rs", "slash"},
+		{"app.ts", "slash"},
+		{"Main.kt", "slash"},
+		{"View.swift", "slash"},
+		{"Main.scala", "slash"},
+		{"Cargo.toml", "hash"},
+		{"config.yaml", "hash"},
+		{"config.yml", "hash"},
+		{"setup.ini", "hash"},
+		{"main.go", "slash"},
+		{"Makefile", "hash"},
+	}
+	var o options
+	newFlags(&o) // for the default settings
+	for _, test := range tests {
+		// Indentings cannot be compared, so compare what they do to some text.
+		want := licenses.Comment("text\n\nmore text", indent[test.want])
+		if got := licenses.Comment("text\n\nmore text", guessIndent(test.path)); got != want {
+			t.Errorf("guessIndent(%q): got %q, want %q", test.path, got, want)
+		}
+		if got := licenses.Comment("text\n\nmore text", o.chooseIndent(nil, test.path)); got != want {
+			t.Errorf("chooseIndent(%q): got %q, want %q", test.path, got, want)
+		}
+	}
+	if in := guessIndent("notes.unknown"); in != nil {
+		t.Errorf("guessIndent(notes.unknown): got %q, want nil", licenses.Comment("text", in))
+	}
+}